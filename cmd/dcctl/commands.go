@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"golang.org/x/term"
+)
+
+type accountInfo struct {
+	Id         deltachat.AccountId `json:"id"`
+	Addr       string              `json:"addr"`
+	Configured bool                `json:"configured"`
+}
+
+func (self *accountInfo) print(out io.Writer) {
+	fmt.Fprintf(out, "%v\t%v\tconfigured=%v\n", self.Id, self.Addr, self.Configured)
+}
+
+type accountList []*accountInfo
+
+func (self accountList) print(out io.Writer) {
+	for _, acc := range self {
+		acc.print(out)
+	}
+}
+
+type configValues map[string]option.Option[string]
+
+func (self configValues) print(out io.Writer) {
+	keys := make([]string, 0, len(self))
+	for key := range self {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(out, "%v=%v\n", key, self[key].UnwrapOr(""))
+	}
+}
+
+type chatInfo struct {
+	Id            deltachat.ChatId `json:"id"`
+	Name          string           `json:"name"`
+	IsGroup       bool             `json:"isGroup"`
+	IsArchived    bool             `json:"isArchived"`
+	FreshMessages uint             `json:"freshMessages"`
+}
+
+type chatList []*chatInfo
+
+func (self chatList) print(out io.Writer) {
+	for _, chat := range self {
+		fmt.Fprintf(out, "%v\t%v\tfresh=%v\n", chat.Id, chat.Name, chat.FreshMessages)
+	}
+}
+
+type sentMsg struct {
+	Id deltachat.MsgId `json:"id"`
+}
+
+func (self *sentMsg) print(out io.Writer) {
+	fmt.Fprintln(out, self.Id)
+}
+
+// Check that the number of arguments is the expected one and parse the account id in the first argument.
+func parseArgs(args []string, minArgs, maxArgs int) (deltachat.AccountId, error) {
+	if len(args) < minArgs || (maxArgs >= 0 && len(args) > maxArgs) {
+		return 0, fmt.Errorf("wrong number of arguments")
+	}
+	accId, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid account id: %q", args[0])
+	}
+	return deltachat.AccountId(accId), nil
+}
+
+func getAccountInfo(rpc *deltachat.Rpc, accId deltachat.AccountId) (*accountInfo, error) {
+	configured, err := rpc.IsConfigured(accId)
+	if err != nil {
+		return nil, err
+	}
+	key := "addr"
+	if configured {
+		key = "configured_addr"
+	}
+	addr, err := rpc.GetConfig(accId, key)
+	if err != nil {
+		return nil, err
+	}
+	return &accountInfo{Id: accId, Addr: addr.UnwrapOr(""), Configured: configured}, nil
+}
+
+func listAccounts(ctx context.Context, cli *cli, args []string) (printer, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("wrong number of arguments")
+	}
+	accIds, err := cli.rpc.GetAllAccountIds()
+	if err != nil {
+		return nil, err
+	}
+	accounts := accountList{}
+	for _, accId := range accIds {
+		acc, err := getAccountInfo(cli.rpc, accId)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, nil
+}
+
+func addAccount(ctx context.Context, cli *cli, args []string) (printer, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("wrong number of arguments")
+	}
+	accId, err := cli.rpc.AddAccount()
+	if err != nil {
+		return nil, err
+	}
+	return &accountInfo{Id: accId}, nil
+}
+
+func removeAccount(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return nil, cli.rpc.RemoveAccount(accId)
+}
+
+func configureAccount(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	password, err := cli.readSecret("DCCTL_PASSWORD", "Password: ")
+	if err != nil {
+		return nil, err
+	}
+	err = cli.rpc.Provision(ctx, accId, &deltachat.AccountConfig{Addr: option.Some(args[1]), MailPassword: option.Some(password)})
+	if err != nil {
+		return nil, err
+	}
+	return getAccountInfo(cli.rpc, accId)
+}
+
+func getConfig(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 2, -1)
+	if err != nil {
+		return nil, err
+	}
+	values, err := cli.rpc.BatchGetConfig(accId, args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return configValues(values), nil
+}
+
+func setConfig(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 2, -1)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseAssignments(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return nil, cli.rpc.BatchSetConfig(accId, values)
+}
+
+// Parse KEY=VALUE arguments, values of the keys supported by deltachat.AccountConfig are validated.
+// An empty value unsets the key.
+func parseAssignments(args []string) (map[string]option.Option[string], error) {
+	values := make(map[string]option.Option[string], len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid argument, expected KEY=VALUE: %q", arg)
+		}
+		if value == "" {
+			values[key] = option.None[string]()
+		} else {
+			values[key] = option.Some(value)
+		}
+	}
+
+	config := &deltachat.AccountConfig{}
+	if err := config.SetConfigMap(values); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func exportBackup(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := cli.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return nil, cli.rpc.ExportBackup(accId, args[1], passphrase)
+}
+
+func importBackup(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := cli.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	if err = cli.rpc.ImportBackup(accId, args[1], passphrase); err != nil {
+		return nil, err
+	}
+	return getAccountInfo(cli.rpc, accId)
+}
+
+func exportKeys(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	return nil, cli.rpc.ExportSelfKeys(accId, args[1])
+}
+
+func listChats(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := cli.rpc.GetChatlistEntries(accId, option.None[uint](), option.None[string](), option.None[deltachat.ContactId]())
+	if err != nil {
+		return nil, err
+	}
+	items, err := cli.rpc.GetChatlistItemsByEntries(accId, entries)
+	if err != nil {
+		return nil, err
+	}
+	chats := chatList{}
+	for _, chatId := range entries {
+		item, ok := items[chatId]
+		if !ok || item == nil {
+			continue
+		}
+		chats = append(chats, &chatInfo{
+			Id:            item.Id,
+			Name:          item.Name,
+			IsGroup:       item.IsGroup,
+			IsArchived:    item.IsArchived,
+			FreshMessages: item.FreshMessageCounter,
+		})
+	}
+	return chats, nil
+}
+
+func sendText(ctx context.Context, cli *cli, args []string) (printer, error) {
+	accId, err := parseArgs(args, 3, 3)
+	if err != nil {
+		return nil, err
+	}
+	chatId, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat id: %q", args[1])
+	}
+	msgId, err := cli.rpc.MiscSendTextMessage(accId, deltachat.ChatId(chatId), args[2])
+	if err != nil {
+		return nil, err
+	}
+	return &sentMsg{Id: msgId}, nil
+}
+
+// Read a secret from the given environment variable or, if it is not set, from the first line of stdin.
+// If stdin is a terminal, the prompt is shown and the secret is read without echo.
+func (self *cli) readSecret(envVar, prompt string) (string, error) {
+	if value, ok := os.LookupEnv(envVar); ok {
+		return value, nil
+	}
+	if file, ok := self.stdin.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		fmt.Fprint(self.stderr, prompt)
+		secret, err := term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(self.stderr)
+		if err != nil {
+			return "", fmt.Errorf("could not read secret from terminal, set %v: %w", envVar, err)
+		}
+		return string(secret), nil
+	}
+	line, err := bufio.NewReader(self.stdin).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("could not read secret from stdin, set %v: %w", envVar, err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Get the backup passphrase from the -passphrase-file option or the DCCTL_PASSPHRASE environment variable.
+func (self *cli) passphrase() (option.Option[string], error) {
+	passphrase := os.Getenv("DCCTL_PASSPHRASE")
+	if self.passphraseFile != "" {
+		data, err := os.ReadFile(self.passphraseFile)
+		if err != nil {
+			return option.None[string](), err
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	}
+	if passphrase == "" {
+		return option.None[string](), nil
+	}
+	return option.Some(passphrase), nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCommand(t *testing.T) {
+	t.Parallel()
+	_, args, err := findCommand([]string{"send", "1", "10", "hi"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"1", "10", "hi"}, args)
+
+	_, args, err = findCommand([]string{"accounts", "remove", "1"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"1"}, args)
+
+	_, _, err = findCommand(nil)
+	assert.NotNil(t, err)
+	_, _, err = findCommand([]string{"accounts"})
+	assert.NotNil(t, err)
+	_, _, err = findCommand([]string{"accounts", "rename"})
+	assert.NotNil(t, err)
+	_, _, err = findCommand([]string{"unknown"})
+	assert.NotNil(t, err)
+}
+
+func TestParseAssignments(t *testing.T) {
+	t.Parallel()
+	values, err := parseAssignments([]string{"mvbox_move=1", "displayname=Echo Bot", "selfstatus=", "ui.custom=x"})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]option.Option[string]{
+		"mvbox_move":  option.Some("1"),
+		"displayname": option.Some("Echo Bot"),
+		"selfstatus":  option.None[string](),
+		"ui.custom":   option.Some("x"),
+	}, values)
+
+	_, err = parseAssignments([]string{"mvbox_move"})
+	assert.NotNil(t, err)
+	_, err = parseAssignments([]string{"mail_port=99999"})
+	assert.NotNil(t, err)
+	_, err = parseAssignments([]string{"bot=yes"})
+	assert.NotNil(t, err)
+}
+
+func TestCli_readSecret(t *testing.T) {
+	cli := &cli{stdin: strings.NewReader("secret\nnext line\n")}
+	password, err := cli.readSecret("DCCTL_TEST_SECRET", "Password: ")
+	require.Nil(t, err)
+	assert.Equal(t, "secret", password)
+
+	t.Setenv("DCCTL_TEST_SECRET", "from env")
+	password, err = cli.readSecret("DCCTL_TEST_SECRET", "Password: ")
+	require.Nil(t, err)
+	assert.Equal(t, "from env", password)
+
+	cli.stdin = strings.NewReader("")
+	_, err = cli.readSecret("DCCTL_TEST_MISSING", "Password: ")
+	assert.NotNil(t, err)
+}
+
+func TestCli_passphrase(t *testing.T) {
+	t.Setenv("DCCTL_PASSPHRASE", "")
+	cli := &cli{}
+	passphrase, err := cli.passphrase()
+	require.Nil(t, err)
+	assert.True(t, passphrase.IsNone())
+
+	t.Setenv("DCCTL_PASSPHRASE", "from env")
+	passphrase, err = cli.passphrase()
+	require.Nil(t, err)
+	assert.Equal(t, option.Some("from env"), passphrase)
+
+	cli.passphraseFile = filepath.Join(acfactory.MkdirTemp(), "passphrase")
+	require.Nil(t, os.WriteFile(cli.passphraseFile, []byte("from file\n"), 0600))
+	passphrase, err = cli.passphrase()
+	require.Nil(t, err)
+	assert.Equal(t, option.Some("from file"), passphrase)
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *deltachat.Rpc, accId deltachat.AccountId) {
+		ctx := context.Background()
+		cli := &cli{rpc: rpc}
+		runCmd := func(jsonOutput bool, args ...string) string {
+			cmd, args, err := findCommand(args)
+			require.Nil(t, err)
+			var out bytes.Buffer
+			require.Nil(t, execute(ctx, cli, cmd, args, jsonOutput, &out))
+			return out.String()
+		}
+
+		var accounts []accountInfo
+		require.Nil(t, json.Unmarshal([]byte(runCmd(true, "accounts", "list")), &accounts))
+		require.Len(t, accounts, 1)
+		assert.Equal(t, accId, accounts[0].Id)
+		assert.True(t, accounts[0].Configured)
+
+		acc := fmt.Sprint(accId)
+		runCmd(false, "config", "set", acc, "displayname=dcctl")
+		assert.Equal(t, "displayname=dcctl\n", runCmd(false, "config", "get", acc, "displayname"))
+
+		selfChat, err := rpc.CreateChatByContactId(accId, deltachat.ContactSelf)
+		require.Nil(t, err)
+		var chats []chatInfo
+		require.Nil(t, json.Unmarshal([]byte(runCmd(true, "chats", acc)), &chats))
+		assert.True(t, slices.ContainsFunc(chats, func(chat chatInfo) bool { return chat.Id == selfChat }))
+
+		var msg sentMsg
+		require.Nil(t, json.Unmarshal([]byte(runCmd(true, "send", acc, fmt.Sprint(selfChat), "hello")), &msg))
+		snapshot, err := rpc.GetMessage(accId, msg.Id)
+		require.Nil(t, err)
+		assert.Equal(t, "hello", snapshot.Text)
+
+		dir := acfactory.MkdirTemp()
+		runCmd(false, "keys", "export", acc, dir)
+		runCmd(false, "backup", "export", acc, dir)
+
+		var added accountInfo
+		require.Nil(t, json.Unmarshal([]byte(runCmd(true, "accounts", "add")), &added))
+		runCmd(false, "accounts", "remove", fmt.Sprint(added.Id))
+	})
+}
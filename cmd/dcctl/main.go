@@ -0,0 +1,161 @@
+// dcctl is a command-line tool to manage Delta Chat accounts.
+//
+// Usage:
+//
+//	dcctl [-accounts-dir DIR] [-server PATH] [-server-log] [-json] [-passphrase-file FILE] COMMAND [ARGS...]
+//
+// Run "dcctl help" to see the available commands.
+//
+// Secrets are never passed as arguments: the account password is read from the DCCTL_PASSWORD
+// environment variable or from stdin, and the backup passphrase from the DCCTL_PASSPHRASE
+// environment variable or the file given with -passphrase-file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/transport"
+)
+
+const usage = `Usage: dcctl [OPTIONS] COMMAND [ARGS...]
+
+Commands:
+  accounts list                       list accounts
+  accounts add                        add a new account
+  accounts remove ACC                 remove an account
+  configure ACC ADDR                  configure an account, the password is read from
+                                      $DCCTL_PASSWORD or stdin
+  config get ACC KEY...               get config values
+  config set ACC KEY=VALUE...         set config values
+  backup export ACC DIR               export a backup of the account to DIR
+  backup import ACC FILE              import a backup into the account
+  keys export ACC DIR                 export the account's keys to DIR
+  chats ACC                           list chats
+  send ACC CHAT TEXT                  send a text message to a chat
+
+Options:
+`
+
+// Output of a command, printed as JSON if the -json option is set.
+type printer interface {
+	print(out io.Writer)
+}
+
+// A command implementation.
+type command func(ctx context.Context, cli *cli, args []string) (printer, error)
+
+type cli struct {
+	rpc            *deltachat.Rpc
+	stdin          io.Reader
+	stderr         io.Writer
+	passphraseFile string
+}
+
+var commands = map[string]map[string]command{
+	"accounts":  {"list": listAccounts, "add": addAccount, "remove": removeAccount},
+	"configure": {"": configureAccount},
+	"config":    {"get": getConfig, "set": setConfig},
+	"backup":    {"export": exportBackup, "import": importBackup},
+	"keys":      {"export": exportKeys},
+	"chats":     {"": listChats},
+	"send":      {"": sendText},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(arguments []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("dcctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	accountsDir := flags.String("accounts-dir", "", "accounts directory, defaults to the deltachat-rpc-server default")
+	server := flags.String("server", "deltachat-rpc-server", "path to the deltachat-rpc-server program")
+	serverLog := flags.Bool("server-log", false, "forward the deltachat-rpc-server log and errors to stderr")
+	jsonOutput := flags.Bool("json", false, "print the output as JSON")
+	passphraseFile := flags.String("passphrase-file", "", "file containing the passphrase for backup export/import, overrides $DCCTL_PASSPHRASE")
+	if err := flags.Parse(arguments); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 1 && flags.Arg(0) == "help" {
+		flags.Usage()
+		return 0
+	}
+	cmd, args, err := findCommand(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		flags.Usage()
+		return 2
+	}
+
+	trans := transport.NewIOTransport()
+	trans.Cmd = *server
+	trans.AccountsDir = *accountsDir
+	trans.Stderr = nil
+	if *serverLog {
+		trans.Stderr = stderr
+	}
+	if err = trans.Open(); err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return 1
+	}
+	defer trans.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	rpc := &deltachat.Rpc{Context: ctx, Transport: trans}
+	cli := &cli{rpc: rpc, stdin: stdin, stderr: stderr, passphraseFile: *passphraseFile}
+	if err = execute(ctx, cli, cmd, args, *jsonOutput, stdout); err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+// Get the command and its arguments from the command line.
+func findCommand(args []string) (command, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, errors.New("missing command")
+	}
+	subcommands, ok := commands[args[0]]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown command: %v", args[0])
+	}
+	if cmd, ok := subcommands[""]; ok {
+		return cmd, args[1:], nil
+	}
+	if len(args) < 2 {
+		return nil, nil, fmt.Errorf("missing %v subcommand", args[0])
+	}
+	cmd, ok := subcommands[args[1]]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown command: %v %v", args[0], args[1])
+	}
+	return cmd, args[2:], nil
+}
+
+func execute(ctx context.Context, cli *cli, cmd command, args []string, jsonOutput bool, out io.Writer) error {
+	result, err := cmd(ctx, cli, args)
+	if err != nil || result == nil {
+		return err
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	result.print(out)
+	return nil
+}
@@ -0,0 +1,277 @@
+// Package to protect bots from message floods.
+//
+// A Guard counts the messages of every contact over sliding time windows and, when a Rule limit is
+// exceeded, warns the contact, ignores its messages or blocks it. Administrators can unblock contacts
+// with a command.
+//
+// Typical usage:
+//
+//	guard := abuse.NewGuard(
+//		abuse.Rule{Window: time.Minute, Limit: 10, Action: abuse.ActionWarn},
+//		abuse.Rule{Window: time.Minute, Limit: 20, Action: abuse.ActionIgnore},
+//		abuse.Rule{Window: time.Hour, Limit: 200, Action: abuse.ActionBlock},
+//	)
+//	guard.AllowAddrs = []string{"friend@example.org"}
+//	guard.IsAdmin = func(bot *deltachat.Bot, accId deltachat.AccountId, contactId deltachat.ContactId) bool {
+//		contact, err := bot.Rpc.GetContact(accId, contactId)
+//		return err == nil && contact.Address == "admin@example.org"
+//	}
+//	bot.OnNewMsg(guard.Middleware(onNewMsg))
+package abuse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+)
+
+// Action taken when a Rule limit is exceeded. Actions are ordered by severity.
+type Action int
+
+const (
+	ActionNone   Action = iota
+	ActionWarn          // Send Guard.WarningText to the contact, the message is processed
+	ActionIgnore        // The message is not processed
+	ActionBlock         // The contact is blocked and the message is not processed
+)
+
+func (self Action) String() string {
+	switch self {
+	case ActionNone:
+		return "None"
+	case ActionWarn:
+		return "Warn"
+	case ActionIgnore:
+		return "Ignore"
+	case ActionBlock:
+		return "Block"
+	default:
+		return fmt.Sprintf("Action(%d)", int(self))
+	}
+}
+
+// A Rule triggers Action when a contact sends more than Limit messages within Window.
+type Rule struct {
+	Window time.Duration
+	Limit  int
+	Action Action
+}
+
+type contactKey struct {
+	accId     deltachat.AccountId
+	contactId deltachat.ContactId
+}
+
+type history struct {
+	times  []time.Time
+	warned time.Time
+}
+
+// Guard is a NewMsgHandler middleware rate limiting incoming messages per contact.
+type Guard struct {
+	Rules []Rule
+	// Addresses of the contacts that are never limited.
+	AllowAddrs []string
+	// Text sent to a contact that triggered ActionWarn, at most once per longest rule window.
+	WarningText string
+	// Command for administrators to unblock a contact, followed by the contact address or ID.
+	UnblockCommand string
+	// Returns true if the given contact can use the administrator commands, if nil commands are disabled.
+	// Administrators are never limited.
+	IsAdmin func(bot *deltachat.Bot, accId deltachat.AccountId, contactId deltachat.ContactId) bool
+	// Optional, called when an action is taken against a contact.
+	OnAction func(bot *deltachat.Bot, accId deltachat.AccountId, contactId deltachat.ContactId, action Action)
+
+	mutex     sync.Mutex
+	history   map[contactKey]*history
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// Create a new Guard applying the given rules.
+func NewGuard(rules ...Rule) *Guard {
+	return &Guard{
+		Rules:          rules,
+		WarningText:    "You are sending too many messages, please slow down.",
+		UnblockCommand: "/unblock",
+		history:        make(map[contactKey]*history),
+		now:            time.Now,
+	}
+}
+
+// Wrap the given NewMsgHandler so that messages from contacts exceeding the limits are not passed to it.
+// The returned handler is meant to be set with Bot.OnNewMsg().
+func (self *Guard) Middleware(next deltachat.NewMsgHandler) deltachat.NewMsgHandler {
+	return func(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) {
+		allowed, _ := self.HandleMsg(bot, accId, msgId)
+		if allowed && next != nil {
+			next(bot, accId, msgId)
+		}
+	}
+}
+
+// Process the given message, returns true if the message should be passed to the bot's handler.
+func (self *Guard) HandleMsg(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) (bool, error) {
+	msg, err := bot.Rpc.GetMessage(accId, msgId)
+	if err != nil {
+		return false, err
+	}
+	if msg.FromId <= deltachat.ContactLastSpecial {
+		return true, nil
+	}
+	if self.IsAdmin != nil && self.IsAdmin(bot, accId, msg.FromId) {
+		if handled, err := self.handleCommand(bot, accId, msg); handled {
+			return false, err
+		}
+		return true, nil
+	}
+	if self.isAllowed(msg) {
+		return true, nil
+	}
+
+	switch action := self.record(accId, msg.FromId); action {
+	case ActionWarn:
+		self.notify(bot, accId, msg.FromId, action)
+		if self.WarningText != "" {
+			_, err = bot.Rpc.MiscSendTextMessage(accId, msg.ChatId, self.WarningText)
+		}
+		return true, err
+	case ActionIgnore:
+		self.notify(bot, accId, msg.FromId, action)
+		return false, nil
+	case ActionBlock:
+		self.notify(bot, accId, msg.FromId, action)
+		self.Reset(accId, msg.FromId)
+		return false, bot.Rpc.BlockContact(accId, msg.FromId)
+	default:
+		return true, nil
+	}
+}
+
+// Forget the messages counted for the given contact.
+func (self *Guard) Reset(accId deltachat.AccountId, contactId deltachat.ContactId) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	delete(self.history, contactKey{accId, contactId})
+}
+
+// Unblock the given contact and forget its counted messages.
+func (self *Guard) Unblock(bot *deltachat.Bot, accId deltachat.AccountId, contactId deltachat.ContactId) error {
+	self.Reset(accId, contactId)
+	return bot.Rpc.UnblockContact(accId, contactId)
+}
+
+// Count a new message of the given contact and get the action to take.
+func (self *Guard) record(accId deltachat.AccountId, contactId deltachat.ContactId) Action {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	now := self.now()
+	var maxWindow time.Duration
+	for _, rule := range self.Rules {
+		maxWindow = max(maxWindow, rule.Window)
+	}
+
+	if now.Sub(self.lastPrune) > maxWindow {
+		self.prune(now, maxWindow)
+	}
+
+	key := contactKey{accId, contactId}
+	item, ok := self.history[key]
+	if !ok {
+		item = &history{}
+		self.history[key] = item
+	}
+	item.times = append(item.times, now)
+	for len(item.times) > 0 && now.Sub(item.times[0]) > maxWindow {
+		item.times = item.times[1:]
+	}
+
+	action := ActionNone
+	for _, rule := range self.Rules {
+		count := 0
+		for _, msgTime := range item.times {
+			if now.Sub(msgTime) <= rule.Window {
+				count++
+			}
+		}
+		if count > rule.Limit && rule.Action > action {
+			action = rule.Action
+		}
+	}
+	if action == ActionWarn {
+		if !item.warned.IsZero() && now.Sub(item.warned) <= maxWindow {
+			return ActionNone
+		}
+		item.warned = now
+	}
+	return action
+}
+
+// Forget the contacts without messages or warnings within the longest rule window.
+func (self *Guard) prune(now time.Time, maxWindow time.Duration) {
+	self.lastPrune = now
+	for key, item := range self.history {
+		if len(item.times) != 0 && now.Sub(item.times[len(item.times)-1]) <= maxWindow {
+			continue
+		}
+		if !item.warned.IsZero() && now.Sub(item.warned) <= maxWindow {
+			continue
+		}
+		delete(self.history, key)
+	}
+}
+
+func (self *Guard) isAllowed(msg *deltachat.MsgSnapshot) bool {
+	if len(self.AllowAddrs) == 0 || msg.Sender == nil {
+		return false
+	}
+	for _, addr := range self.AllowAddrs {
+		if strings.EqualFold(addr, msg.Sender.Address) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *Guard) handleCommand(bot *deltachat.Bot, accId deltachat.AccountId, msg *deltachat.MsgSnapshot) (bool, error) {
+	args := strings.Fields(msg.Text)
+	if self.UnblockCommand == "" || len(args) != 2 || !strings.EqualFold(args[0], self.UnblockCommand) {
+		return false, nil
+	}
+	var contactId deltachat.ContactId
+	if id, err := strconv.ParseUint(args[1], 10, 32); err == nil {
+		contactId = deltachat.ContactId(id)
+	} else {
+		result, err := bot.Rpc.LookupContactIdByAddr(accId, args[1])
+		if err != nil {
+			return true, err
+		}
+		contactId = result.UnwrapOr(0)
+	}
+
+	reply := "Unknown contact: " + args[1]
+	var err error
+	if contactId != 0 {
+		if err = self.Unblock(bot, accId, contactId); err == nil {
+			reply = "Unblocked: " + args[1]
+		}
+	}
+	if err != nil {
+		reply = "Error: " + err.Error()
+	}
+	_, sendErr := bot.Rpc.MiscSendTextMessage(accId, msg.ChatId, reply)
+	if err == nil {
+		err = sendErr
+	}
+	return true, err
+}
+
+func (self *Guard) notify(bot *deltachat.Bot, accId deltachat.AccountId, contactId deltachat.ContactId, action Action) {
+	if self.OnAction != nil {
+		self.OnAction(bot, accId, contactId, action)
+	}
+}
@@ -0,0 +1,93 @@
+package abuse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuard_record(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000, 0)
+	guard := NewGuard(
+		Rule{Window: time.Minute, Limit: 2, Action: ActionWarn},
+		Rule{Window: time.Minute, Limit: 3, Action: ActionIgnore},
+		Rule{Window: time.Hour, Limit: 5, Action: ActionBlock},
+	)
+	guard.now = func() time.Time { return now }
+
+	assert.Equal(t, ActionNone, guard.record(1, 10))
+	assert.Equal(t, ActionNone, guard.record(1, 10))
+	assert.Equal(t, ActionWarn, guard.record(1, 10))
+	assert.Equal(t, ActionIgnore, guard.record(1, 10))
+	assert.Equal(t, ActionNone, guard.record(1, 11))
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, ActionNone, guard.record(1, 10)) // Only this message is in the minute window
+	assert.Equal(t, ActionBlock, guard.record(1, 10))
+
+	guard.Reset(1, 10)
+	assert.Equal(t, ActionNone, guard.record(1, 10))
+	assert.Equal(t, "Ignore", ActionIgnore.String())
+
+	// Contacts without recent messages or warnings are forgotten.
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, ActionNone, guard.record(1, 10))
+	assert.NotContains(t, guard.history, contactKey{1, 11})
+	assert.Contains(t, guard.history, contactKey{1, 10})
+}
+
+func TestGuard_record_warnOnce(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000, 0)
+	guard := NewGuard(Rule{Window: time.Minute, Limit: 1, Action: ActionWarn})
+	guard.now = func() time.Time { return now }
+
+	assert.Equal(t, ActionNone, guard.record(1, 10))
+	assert.Equal(t, ActionWarn, guard.record(1, 10))
+	// The limit is still exceeded but the contact was already warned within the window.
+	assert.Equal(t, ActionNone, guard.record(1, 10))
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, ActionNone, guard.record(1, 10))
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, ActionNone, guard.record(1, 10))
+	assert.Equal(t, ActionWarn, guard.record(1, 10))
+}
+
+func TestGuard(t *testing.T) {
+	t.Parallel()
+	acfactory.WithRunningBot(func(bot *deltachat.Bot, botAcc deltachat.AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *deltachat.Rpc, accId deltachat.AccountId) {
+			actions := make(chan Action, 10)
+			received := make(chan string, 10)
+			guard := NewGuard(Rule{Window: time.Hour, Limit: 1, Action: ActionBlock})
+			guard.OnAction = func(bot *deltachat.Bot, accId deltachat.AccountId, contactId deltachat.ContactId, action Action) {
+				actions <- action
+			}
+			bot.OnNewMsg(guard.Middleware(func(bot *deltachat.Bot, botAcc deltachat.AccountId, msgId deltachat.MsgId) {
+				msg, _ := bot.Rpc.GetMessage(botAcc, msgId)
+				received <- msg.Text
+			}))
+
+			chatWithBot := acfactory.CreateChat(accRpc, accId, bot.Rpc, botAcc)
+			_, err := accRpc.MiscSendTextMessage(accId, chatWithBot, "first")
+			require.Nil(t, err)
+			assert.Equal(t, "first", <-received)
+			_, err = accRpc.MiscSendTextMessage(accId, chatWithBot, "second")
+			require.Nil(t, err)
+			assert.Equal(t, ActionBlock, <-actions)
+
+			contacts, err := bot.Rpc.GetBlockedContacts(botAcc)
+			require.Nil(t, err)
+			require.Len(t, contacts, 1)
+			require.Nil(t, guard.Unblock(bot, botAcc, contacts[0].Id))
+			contacts, err = bot.Rpc.GetBlockedContacts(botAcc)
+			require.Nil(t, err)
+			assert.Empty(t, contacts)
+		})
+	})
+}
@@ -0,0 +1,318 @@
+package deltachat
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+)
+
+// Certificate checks of an IMAP/SMTP connection.
+type CertificateChecks int
+
+const (
+	CertificateChecksAutomatic CertificateChecks = iota
+	CertificateChecksStrict
+	CertificateChecksAcceptInvalid
+)
+
+// Which messages to show in the chat list, see AccountConfig.ShowEmails.
+type ShowEmails int
+
+const (
+	ShowEmailsOff ShowEmails = iota
+	ShowEmailsAcceptedContacts
+	ShowEmailsAll
+)
+
+// Quality of the outgoing images and videos, see AccountConfig.MediaQuality.
+type MediaQuality int
+
+const (
+	MediaQualityBalanced MediaQuality = iota
+	MediaQualityWorse
+)
+
+// Type of the key to generate, see AccountConfig.KeyGenType.
+type KeyGenType int
+
+const (
+	KeyGenTypeDefault KeyGenType = iota
+	KeyGenTypeRsa2048
+	KeyGenTypeEd25519
+	KeyGenTypeRsa4096
+)
+
+// InvalidConfigErr is returned when an AccountConfig value is not valid or can not be parsed.
+type InvalidConfigErr struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+func (self *InvalidConfigErr) Error() string {
+	return fmt.Sprintf("invalid value %q for config key %q: %v", self.Value, self.Key, self.Reason)
+}
+
+// Typed account configuration, see Rpc.SetAccountConfig() and Rpc.GetAccountConfig().
+// Unset values (option.None) are left untouched when setting the configuration.
+type AccountConfig struct {
+	Addr                  option.Option[string]
+	MailServer            option.Option[string]
+	MailUser              option.Option[string]
+	MailPassword          option.Option[string]
+	MailPort              option.Option[int]
+	MailSecurity          option.Option[Security]
+	ImapCertificateChecks option.Option[CertificateChecks]
+	SendServer            option.Option[string]
+	SendUser              option.Option[string]
+	SendPassword          option.Option[string]
+	SendPort              option.Option[int]
+	SendSecurity          option.Option[Security]
+	SmtpCertificateChecks option.Option[CertificateChecks]
+	ProxyEnabled          option.Option[bool]
+	ProxyUrl              option.Option[string]
+
+	DisplayName option.Option[string]
+	SelfStatus  option.Option[string]
+	SelfAvatar  option.Option[string] // Path to the avatar image
+	Bot         option.Option[bool]
+
+	E2eeEnabled           option.Option[bool]
+	MdnsEnabled           option.Option[bool]
+	BccSelf               option.Option[bool]
+	MvboxMove             option.Option[bool]
+	OnlyFetchMvbox        option.Option[bool]
+	ShowEmails            option.Option[ShowEmails]
+	MediaQuality          option.Option[MediaQuality]
+	FetchExistingMsgs     option.Option[bool]
+	KeyGenType            option.Option[KeyGenType]
+	SyncMsgs              option.Option[bool]
+	DisableIdle           option.Option[bool]
+	SaveMimeHeaders       option.Option[bool]
+	DeleteDeviceAfter     option.Option[time.Duration] // Zero means never, precision is one second
+	DeleteServerAfter     option.Option[time.Duration] // Zero means never, one second means immediately
+	DownloadLimit         option.Option[int]           // In bytes, zero means no limit
+	WebrtcInstance        option.Option[string]
+	VerifiedOneOnOneChats option.Option[bool]
+}
+
+// A config key with the functions to convert its typed value from and to the core's string representation.
+type configField struct {
+	key       string
+	isSome    func() bool
+	marshal   func() (string, error)
+	unmarshal func(value option.Option[string]) error
+}
+
+func newConfigField[T any](key string, value *option.Option[T], format func(T) (string, error), parse func(string) (T, error)) configField {
+	return configField{
+		key:    key,
+		isSome: value.IsSome,
+		marshal: func() (string, error) {
+			raw, err := format(value.Unwrap())
+			if err != nil {
+				return "", &InvalidConfigErr{Key: key, Value: fmt.Sprint(value.Unwrap()), Reason: err.Error()}
+			}
+			return raw, nil
+		},
+		unmarshal: func(raw option.Option[string]) error {
+			if raw.IsNone() {
+				*value = option.None[T]()
+				return nil
+			}
+			parsed, err := parse(raw.Unwrap())
+			if err != nil {
+				return &InvalidConfigErr{Key: key, Value: raw.Unwrap(), Reason: err.Error()}
+			}
+			*value = option.Some(parsed)
+			return nil
+		},
+	}
+}
+
+func stringField(key string, value *option.Option[string]) configField {
+	same := func(value string) (string, error) { return value, nil }
+	return newConfigField(key, value, same, same)
+}
+
+func boolField(key string, value *option.Option[bool]) configField {
+	format := func(value bool) (string, error) {
+		if value {
+			return "1", nil
+		}
+		return "0", nil
+	}
+	parse := func(raw string) (bool, error) {
+		switch raw {
+		case "0", "":
+			return false, nil
+		case "1":
+			return true, nil
+		default:
+			return false, fmt.Errorf("expected 0 or 1")
+		}
+	}
+	return newConfigField(key, value, format, parse)
+}
+
+func intField(key string, value *option.Option[int], minValue, maxValue int) configField {
+	format := func(value int) (string, error) {
+		if value < minValue || value > maxValue {
+			return "", fmt.Errorf("out of range [%v, %v]", minValue, maxValue)
+		}
+		return strconv.Itoa(value), nil
+	}
+	parse := func(raw string) (int, error) {
+		if raw == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(raw)
+	}
+	return newConfigField(key, value, format, parse)
+}
+
+func enumField[T ~int](key string, value *option.Option[T], maxValue T) configField {
+	format := func(value T) (string, error) {
+		if value < 0 || value > maxValue {
+			return "", fmt.Errorf("out of range [0, %v]", int(maxValue))
+		}
+		return strconv.Itoa(int(value)), nil
+	}
+	parse := func(raw string) (T, error) {
+		if raw == "" {
+			return 0, nil
+		}
+		parsed, err := strconv.Atoi(raw)
+		return T(parsed), err
+	}
+	return newConfigField(key, value, format, parse)
+}
+
+func durationField(key string, value *option.Option[time.Duration]) configField {
+	format := func(value time.Duration) (string, error) {
+		if value < 0 {
+			return "", fmt.Errorf("negative duration")
+		}
+		if value > 0 && value < time.Second {
+			return "", fmt.Errorf("less than one second")
+		}
+		return strconv.FormatInt(int64(value/time.Second), 10), nil
+	}
+	parse := func(raw string) (time.Duration, error) {
+		if raw == "" {
+			return 0, nil
+		}
+		seconds, err := strconv.ParseInt(raw, 10, 64)
+		return time.Duration(seconds) * time.Second, err
+	}
+	return newConfigField(key, value, format, parse)
+}
+
+func (self *AccountConfig) fields() []configField {
+	return []configField{
+		stringField("addr", &self.Addr),
+		stringField("mail_server", &self.MailServer),
+		stringField("mail_user", &self.MailUser),
+		stringField("mail_pw", &self.MailPassword),
+		intField("mail_port", &self.MailPort, 0, 65535),
+		enumField("mail_security", &self.MailSecurity, SecurityPlain),
+		enumField("imap_certificate_checks", &self.ImapCertificateChecks, CertificateChecksAcceptInvalid),
+		stringField("send_server", &self.SendServer),
+		stringField("send_user", &self.SendUser),
+		stringField("send_pw", &self.SendPassword),
+		intField("send_port", &self.SendPort, 0, 65535),
+		enumField("send_security", &self.SendSecurity, SecurityPlain),
+		enumField("smtp_certificate_checks", &self.SmtpCertificateChecks, CertificateChecksAcceptInvalid),
+		boolField("proxy_enabled", &self.ProxyEnabled),
+		stringField("proxy_url", &self.ProxyUrl),
+		stringField("displayname", &self.DisplayName),
+		stringField("selfstatus", &self.SelfStatus),
+		stringField("selfavatar", &self.SelfAvatar),
+		boolField("bot", &self.Bot),
+		boolField("e2ee_enabled", &self.E2eeEnabled),
+		boolField("mdns_enabled", &self.MdnsEnabled),
+		boolField("bcc_self", &self.BccSelf),
+		boolField("mvbox_move", &self.MvboxMove),
+		boolField("only_fetch_mvbox", &self.OnlyFetchMvbox),
+		enumField("show_emails", &self.ShowEmails, ShowEmailsAll),
+		enumField("media_quality", &self.MediaQuality, MediaQualityWorse),
+		boolField("fetch_existing_msgs", &self.FetchExistingMsgs),
+		enumField("key_gen_type", &self.KeyGenType, KeyGenTypeRsa4096),
+		boolField("sync_msgs", &self.SyncMsgs),
+		boolField("disable_idle", &self.DisableIdle),
+		boolField("save_mime_headers", &self.SaveMimeHeaders),
+		durationField("delete_device_after", &self.DeleteDeviceAfter),
+		durationField("delete_server_after", &self.DeleteServerAfter),
+		intField("download_limit", &self.DownloadLimit, 0, int(^uint32(0)>>1)),
+		stringField("webrtc_instance", &self.WebrtcInstance),
+		boolField("verified_one_on_one_chats", &self.VerifiedOneOnOneChats),
+	}
+}
+
+// Get the config keys supported by AccountConfig.
+func AccountConfigKeys() []string {
+	fields := (&AccountConfig{}).fields()
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		keys[i] = field.key
+	}
+	return keys
+}
+
+// Check that all set values are valid. Returns an InvalidConfigErr for the first invalid value.
+func (self *AccountConfig) Validate() error {
+	_, err := self.ConfigMap()
+	return err
+}
+
+// Get the set values as expected by Rpc.BatchSetConfig(). Unset values are omitted.
+// Returns an InvalidConfigErr if some value is not valid.
+func (self *AccountConfig) ConfigMap() (map[string]option.Option[string], error) {
+	config := make(map[string]option.Option[string])
+	for _, field := range self.fields() {
+		if !field.isSome() {
+			continue
+		}
+		value, err := field.marshal()
+		if err != nil {
+			return nil, err
+		}
+		config[field.key] = option.Some(value)
+	}
+	return config, nil
+}
+
+// Set the values from a map as returned by Rpc.BatchGetConfig(). Keys missing in the map are left untouched,
+// unknown keys are ignored. Returns an InvalidConfigErr if some value can not be parsed.
+func (self *AccountConfig) SetConfigMap(config map[string]option.Option[string]) error {
+	for _, field := range self.fields() {
+		if value, ok := config[field.key]; ok {
+			if err := field.unmarshal(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Set the account configuration values present in the given AccountConfig.
+// The values are validated before sending them to the core.
+func (self *Rpc) SetAccountConfig(accountId AccountId, config *AccountConfig) error {
+	configMap, err := config.ConfigMap()
+	if err != nil {
+		return err
+	}
+	return self.BatchSetConfig(accountId, configMap)
+}
+
+// Get the account configuration.
+func (self *Rpc) GetAccountConfig(accountId AccountId) (*AccountConfig, error) {
+	values, err := self.BatchGetConfig(accountId, AccountConfigKeys())
+	if err != nil {
+		return nil, err
+	}
+	config := &AccountConfig{}
+	return config, config.SetConfigMap(values)
+}
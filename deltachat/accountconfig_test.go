@@ -0,0 +1,80 @@
+package deltachat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountConfig_ConfigMap(t *testing.T) {
+	t.Parallel()
+	config := &AccountConfig{
+		MailPort:          option.Some(993),
+		MailSecurity:      option.Some(SecurityStartTls),
+		MvboxMove:         option.Some(false),
+		Bot:               option.Some(true),
+		DeleteServerAfter: option.Some(time.Hour),
+		DisplayName:       option.Some("bot"),
+	}
+	cfgMap, err := config.ConfigMap()
+	require.Nil(t, err)
+	assert.Equal(t, map[string]option.Option[string]{
+		"mail_port":           option.Some("993"),
+		"mail_security":       option.Some("2"),
+		"mvbox_move":          option.Some("0"),
+		"bot":                 option.Some("1"),
+		"delete_server_after": option.Some("3600"),
+		"displayname":         option.Some("bot"),
+	}, cfgMap)
+
+	config2 := &AccountConfig{}
+	require.Nil(t, config2.SetConfigMap(cfgMap))
+	assert.Equal(t, config, config2)
+
+	var cfgErr *InvalidConfigErr
+	err = config2.SetConfigMap(map[string]option.Option[string]{"bot": option.Some("yes")})
+	require.ErrorAs(t, err, &cfgErr)
+	assert.Equal(t, "bot", cfgErr.Key)
+	require.Nil(t, config2.SetConfigMap(map[string]option.Option[string]{"mvbox_move": option.None[string]()}))
+	assert.True(t, config2.MvboxMove.IsNone())
+}
+
+func TestAccountConfig_Validate(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, (&AccountConfig{}).Validate())
+	invalid := []*AccountConfig{
+		{MailPort: option.Some(70000)},
+		{SendSecurity: option.Some(Security(7))},
+		{ShowEmails: option.Some(ShowEmails(-1))},
+		{DeleteDeviceAfter: option.Some(-time.Second)},
+		{DeleteServerAfter: option.Some(time.Millisecond)},
+	}
+	for _, config := range invalid {
+		var cfgErr *InvalidConfigErr
+		assert.ErrorAs(t, config.Validate(), &cfgErr)
+	}
+}
+
+func TestRpc_SetAccountConfig(t *testing.T) {
+	t.Parallel()
+	acfactory.WithUnconfiguredAccount(func(rpc *Rpc, accId AccountId) {
+		err := rpc.SetAccountConfig(accId, &AccountConfig{MailPort: option.Some(-1)})
+		assert.NotNil(t, err)
+
+		config := &AccountConfig{
+			DisplayName:       option.Some("bot"),
+			Bot:               option.Some(true),
+			DeleteDeviceAfter: option.Some(24 * time.Hour),
+		}
+		require.Nil(t, rpc.SetAccountConfig(accId, config))
+		config2, err := rpc.GetAccountConfig(accId)
+		require.Nil(t, err)
+		assert.Equal(t, "bot", config2.DisplayName.Unwrap())
+		assert.True(t, config2.Bot.Unwrap())
+		assert.Equal(t, 24*time.Hour, config2.DeleteDeviceAfter.Unwrap())
+		assert.Equal(t, 3143, config2.MailPort.Unwrap())
+	})
+}
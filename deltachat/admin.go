@@ -0,0 +1,264 @@
+package deltachat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Number of log lines kept for the /logs admin command.
+const adminLogSize = 100
+
+// Minimum time between two error reports in the admin chat of an account,
+// errors happening in between are only logged.
+const adminReportInterval = time.Minute
+
+// Keeps the admin chats of a Bot and the data reported by the admin commands.
+type adminState struct {
+	mutex     sync.Mutex
+	chats     map[AccountId]ChatId
+	started   time.Time
+	events    uint64
+	msgs      uint64
+	errors    uint64
+	logs      []string
+	logsStart int
+	reported  map[AccountId]time.Time
+}
+
+func (self *adminState) log(line string) {
+	if len(self.logs) < adminLogSize {
+		self.logs = append(self.logs, line)
+		return
+	}
+	self.logs[self.logsStart] = line
+	self.logsStart = (self.logsStart + 1) % adminLogSize
+}
+
+func (self *adminState) lastLogs(count int) []string {
+	logs := append(append([]string{}, self.logs[self.logsStart:]...), self.logs[:self.logsStart]...)
+	return logs[max(len(logs)-count, 0):]
+}
+
+// Set the admin chat of the given account. A zero chatId disables the admin chat.
+//
+// Messages sent to the admin chat starting with one of the following commands are processed by the bot
+// and not passed to the NewMsgHandler:
+//
+//	/status                  bot and account status
+//	/connectivity            connectivity of the account
+//	/block <address or ID>   block a contact
+//	/stats                   event and message counters
+//	/logs [count]            last log lines, 20 by default
+//
+// Errors (EventError) of the account are also reported in the admin chat.
+// The admin chat is persisted with Bot.Store().
+func (self *Bot) SetAdminChat(accId AccountId, chatId ChatId) error {
+	store := self.Store(accId)
+	var err error
+	if chatId == 0 {
+		err = store.Delete("admin_chat")
+	} else {
+		err = store.Set("admin_chat", chatId)
+	}
+	if err != nil {
+		return err
+	}
+	self.admin.mutex.Lock()
+	defer self.admin.mutex.Unlock()
+	if self.admin.chats == nil {
+		self.admin.chats = make(map[AccountId]ChatId)
+	}
+	self.admin.chats[accId] = chatId
+	return nil
+}
+
+// Get the admin chat of the given account, false is returned if there is no admin chat.
+func (self *Bot) AdminChat(accId AccountId) (ChatId, bool) {
+	self.admin.mutex.Lock()
+	chatId, ok := self.admin.chats[accId]
+	self.admin.mutex.Unlock()
+	if !ok {
+		self.Store(accId).Get("admin_chat", &chatId) //nolint:errcheck
+		self.admin.mutex.Lock()
+		if self.admin.chats == nil {
+			self.admin.chats = make(map[AccountId]ChatId)
+		}
+		self.admin.chats[accId] = chatId
+		self.admin.mutex.Unlock()
+	}
+	return chatId, chatId != 0
+}
+
+// Create a protected group with the given name, make it the admin chat of the account
+// and add the given contacts to it. Only verified contacts can be added to protected groups.
+func (self *Bot) CreateAdminGroup(accId AccountId, name string, admins ...ContactId) (ChatId, error) {
+	chatId, err := self.Rpc.CreateGroupChat(accId, name, true)
+	if err != nil {
+		return 0, err
+	}
+	for _, contactId := range admins {
+		if err = self.Rpc.AddContactToChat(accId, chatId, contactId); err != nil {
+			return 0, err
+		}
+	}
+	return chatId, self.SetAdminChat(accId, chatId)
+}
+
+// Update the admin counters and report errors, called for every event.
+func (self *Bot) adminOnEvent(accId AccountId, event Event) {
+	self.admin.mutex.Lock()
+	self.admin.events++
+	var report string
+	switch ev := event.(type) {
+	case EventInfo:
+		self.admin.log(fmt.Sprintf("[%v] INFO %v", accId, ev.Msg))
+	case EventWarning:
+		self.admin.log(fmt.Sprintf("[%v] WARNING %v", accId, ev.Msg))
+	case EventError:
+		self.admin.errors++
+		self.admin.log(fmt.Sprintf("[%v] ERROR %v", accId, ev.Msg))
+		if now := time.Now(); now.Sub(self.admin.reported[accId]) >= adminReportInterval {
+			if self.admin.reported == nil {
+				self.admin.reported = make(map[AccountId]time.Time)
+			}
+			self.admin.reported[accId] = now
+			report = ev.Msg
+		}
+	}
+	self.admin.mutex.Unlock()
+
+	if report != "" {
+		if chatId, ok := self.AdminChat(accId); ok {
+			self.Rpc.MiscSendTextMessage(accId, chatId, "⚠️ Error: "+report) //nolint:errcheck
+		}
+	}
+}
+
+// Log an error of the bot itself, it is counted and shown by the /stats and /logs admin commands.
+func (self *Bot) logError(accId AccountId, err error) {
+	self.admin.mutex.Lock()
+	defer self.admin.mutex.Unlock()
+	self.admin.errors++
+	self.admin.log(fmt.Sprintf("[%v] ERROR %v", accId, err))
+}
+
+// Process the given message if it is an admin command, returns true if the message was a command.
+func (self *Bot) handleAdminCommand(accId AccountId, msgId MsgId) bool {
+	self.admin.mutex.Lock()
+	self.admin.msgs++
+	self.admin.mutex.Unlock()
+
+	chatId, ok := self.AdminChat(accId)
+	if !ok {
+		return false
+	}
+	msg, err := self.Rpc.GetMessage(accId, msgId)
+	if err != nil || msg.ChatId != chatId || msg.FromId <= ContactLastSpecial {
+		return false
+	}
+	args := strings.Fields(msg.Text)
+	if len(args) == 0 {
+		return false
+	}
+
+	var reply string
+	switch args[0] {
+	case "/status":
+		reply, err = self.adminStatus(accId)
+	case "/connectivity":
+		reply, err = self.adminConnectivity(accId)
+	case "/block":
+		reply, err = self.adminBlock(accId, args[1:])
+	case "/stats":
+		reply = self.adminStats()
+	case "/logs":
+		reply = self.adminLogs(args[1:])
+	default:
+		return false
+	}
+	if err != nil {
+		reply = "Error: " + err.Error()
+	}
+	self.Rpc.MiscSendTextMessage(accId, chatId, reply) //nolint:errcheck
+	return true
+}
+
+func (self *Bot) adminStatus(accId AccountId) (string, error) {
+	addr, err := self.Rpc.GetConfig(accId, "configured_addr")
+	if err != nil {
+		return "", err
+	}
+	configured, err := self.Rpc.IsConfigured(accId)
+	if err != nil {
+		return "", err
+	}
+	connectivity, err := self.Rpc.GetConnectivity(accId)
+	if err != nil {
+		return "", err
+	}
+	self.admin.mutex.Lock()
+	uptime := time.Since(self.admin.started).Round(time.Second)
+	self.admin.mutex.Unlock()
+	text := fmt.Sprintf("Account: %v\nConfigured: %v\nConnectivity: %v\nRunning: %v\nUptime: %v",
+		addr.UnwrapOr(""), configured, NewConnectivity(connectivity), self.IsRunning(), uptime)
+	if self.sendQueue != nil {
+		text += fmt.Sprintf("\nSend queue: %v", self.sendQueue.Metrics().Depth)
+	}
+	return text, nil
+}
+
+func (self *Bot) adminConnectivity(accId AccountId) (string, error) {
+	connectivity, err := self.Rpc.GetConnectivity(accId)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v (%v)", NewConnectivity(connectivity), connectivity), nil
+}
+
+func (self *Bot) adminBlock(accId AccountId, args []string) (string, error) {
+	if len(args) != 1 {
+		return "Usage: /block <address or contact ID>", nil
+	}
+	var contactId ContactId
+	if id, err := strconv.ParseUint(args[0], 10, 32); err == nil {
+		contactId = ContactId(id)
+	} else {
+		result, err := self.Rpc.LookupContactIdByAddr(accId, args[0])
+		if err != nil {
+			return "", err
+		}
+		contactId = result.UnwrapOr(0)
+	}
+	if contactId <= ContactLastSpecial {
+		return "Unknown contact: " + args[0], nil
+	}
+	if err := self.Rpc.BlockContact(accId, contactId); err != nil {
+		return "", err
+	}
+	return "Blocked: " + args[0], nil
+}
+
+func (self *Bot) adminStats() string {
+	self.admin.mutex.Lock()
+	defer self.admin.mutex.Unlock()
+	return fmt.Sprintf("Events: %v\nMessages: %v\nErrors: %v", self.admin.events, self.admin.msgs, self.admin.errors)
+}
+
+func (self *Bot) adminLogs(args []string) string {
+	count := 20
+	if len(args) > 0 {
+		if value, err := strconv.Atoi(args[0]); err == nil && value > 0 {
+			count = value
+		}
+	}
+	self.admin.mutex.Lock()
+	logs := self.admin.lastLogs(count)
+	self.admin.mutex.Unlock()
+	if len(logs) == 0 {
+		return "No logs."
+	}
+	return strings.Join(logs, "\n")
+}
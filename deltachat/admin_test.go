@@ -0,0 +1,65 @@
+package deltachat
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminState_logs(t *testing.T) {
+	t.Parallel()
+	state := &adminState{}
+	assert.Empty(t, state.lastLogs(10))
+	for i := 0; i < adminLogSize+5; i++ {
+		state.log(fmt.Sprint(i))
+	}
+	assert.Equal(t, []string{"102", "103", "104"}, state.lastLogs(3))
+	logs := state.lastLogs(1000)
+	assert.Len(t, logs, adminLogSize)
+	assert.Equal(t, "5", logs[0])
+}
+
+func TestBot_SetAdminChat(t *testing.T) {
+	t.Parallel()
+	acfactory.WithRunningBot(func(bot *Bot, botAcc AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *Rpc, accId AccountId) {
+			received := make(chan string, 1)
+			bot.OnNewMsg(func(bot *Bot, botAcc AccountId, msgId MsgId) {
+				msg, _ := bot.Rpc.GetMessage(botAcc, msgId)
+				received <- msg.Text
+			})
+
+			chatWithBot := acfactory.CreateChat(accRpc, accId, bot.Rpc, botAcc)
+			_, err := accRpc.MiscSendTextMessage(accId, chatWithBot, "hi")
+			require.Nil(t, err)
+			assert.Equal(t, "hi", <-received)
+
+			_, ok := bot.AdminChat(botAcc)
+			assert.False(t, ok)
+			chatId := acfactory.CreateChat(bot.Rpc, botAcc, accRpc, accId)
+			require.Nil(t, bot.SetAdminChat(botAcc, chatId))
+			adminChat, ok := bot.AdminChat(botAcc)
+			assert.True(t, ok)
+			assert.Equal(t, chatId, adminChat)
+
+			_, err = accRpc.MiscSendTextMessage(accId, chatWithBot, "/stats")
+			require.Nil(t, err)
+			assert.True(t, strings.HasPrefix(acfactory.NextMsg(accRpc, accId).Text, "Events: "))
+
+			_, err = accRpc.MiscSendTextMessage(accId, chatWithBot, "/status")
+			require.Nil(t, err)
+			assert.Contains(t, acfactory.NextMsg(accRpc, accId).Text, "Configured: true")
+
+			_, err = accRpc.MiscSendTextMessage(accId, chatWithBot, "not a command")
+			require.Nil(t, err)
+			assert.Equal(t, "not a command", <-received)
+
+			require.Nil(t, bot.SetAdminChat(botAcc, 0))
+			_, ok = bot.AdminChat(botAcc)
+			assert.False(t, ok)
+		})
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
 )
@@ -28,6 +30,15 @@ type Bot struct {
 	ctxMutex         sync.Mutex
 	ctx              context.Context
 	stop             context.CancelFunc
+	stores           map[AccountId]*Store
+	storesMutex      sync.Mutex
+	deliveries       deliveryTracker
+	sendQueue        *SendQueue
+
+	contactRequestPolicy ContactRequestPolicy
+	admin                adminState
+	provisions           provisionTracker
+	lastEvent            atomic.Int64 // Unix time in nanoseconds of the last event
 }
 
 // Create a new Bot that will process events for all created accounts.
@@ -96,7 +107,15 @@ func (self *Bot) Run() error {
 		return &BotRunningErr{}
 	}
 	self.ctx, self.stop = context.WithCancel(context.Background())
+	done := self.ctx.Done()
 	self.ctxMutex.Unlock()
+	self.admin.mutex.Lock()
+	self.admin.started = time.Now()
+	self.admin.mutex.Unlock()
+
+	if self.sendQueue != nil {
+		go self.sendQueue.run(self, done)
+	}
 
 	self.Rpc.StartIoForAllAccounts() //nolint:errcheck
 	ids, _ := self.Rpc.GetAllAccountIds()
@@ -155,6 +174,10 @@ func (self *Bot) Stop() {
 }
 
 func (self *Bot) onEvent(accId AccountId, event Event) {
+	self.lastEvent.Store(time.Now().UnixNano())
+	self.deliveries.onEvent(self.Rpc, accId, event)
+	self.provisions.onEvent(accId, event)
+	self.adminOnEvent(accId, event)
 	self.handlerMapMutex.RLock()
 	handler, ok := self.handlerMap[event.eventType()]
 	self.handlerMapMutex.RUnlock()
@@ -172,7 +195,10 @@ func (self *Bot) processMessages(accId AccountId) {
 	}
 	for _, msgId := range msgIds {
 		self.Rpc.SetConfig(accId, "last_msg_id", option.Some(fmt.Sprintf("%v", msgId))) //nolint:errcheck
-		if self.newMsgHandler != nil {
+		if self.handleAdminCommand(accId, msgId) {
+			continue
+		}
+		if self.newMsgHandler != nil && self.applyContactRequestPolicy(accId, msgId) {
 			self.newMsgHandler(self, accId, msgId)
 		}
 	}
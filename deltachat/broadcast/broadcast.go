@@ -0,0 +1,387 @@
+// Package to manage broadcast lists and send mass mailings from bots.
+//
+// A Broadcaster keeps a persistent set of subscribers per account (see Bot.Store()), handles
+// subscribe/unsubscribe commands, keeps a Delta Chat broadcast list in sync with the subscribers
+// and sends messages to every subscriber individually through the bot's SendQueue, with per-recipient
+// delivery tracking.
+//
+// Typical usage:
+//
+//	bot.SetSendQueue(deltachat.NewSendQueue(deltachat.RateLimit{Interval: 2 * time.Second}, deltachat.RateLimit{}))
+//	news := broadcast.NewBroadcaster("news")
+//	bot.OnNewMsg(news.Middleware(onNewMsg))
+//	go func() {
+//		campaign, _ := news.Send(ctx, bot, accId, deltachat.MsgData{Text: "Hello subscribers!"})
+//		<-campaign.Done()
+//		log.Println("failed:", campaign.Failed())
+//	}()
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/groups"
+)
+
+// NoSendQueueErr is returned by Broadcaster.Send() if the bot has no SendQueue, see Bot.SetSendQueue().
+type NoSendQueueErr struct{}
+
+func (self *NoSendQueueErr) Error() string {
+	return "the bot has no send queue"
+}
+
+// Delivery status of a message sent to a subscriber.
+type Status int
+
+const (
+	StatusPending Status = iota
+	StatusDelivered
+	StatusFailed
+)
+
+func (self Status) String() string {
+	switch self {
+	case StatusPending:
+		return "Pending"
+	case StatusDelivered:
+		return "Delivered"
+	case StatusFailed:
+		return "Failed"
+	default:
+		return fmt.Sprintf("Status(%d)", int(self))
+	}
+}
+
+// Outcome of a message sent to a subscriber.
+type Recipient struct {
+	ContactId deltachat.ContactId
+	MsgId     deltachat.MsgId // Zero if the message was not sent yet or could not be sent
+	Status    Status
+	Error     string // Reason of the failure, if Status is StatusFailed
+}
+
+// Campaign tracks the delivery of a message sent to all the subscribers.
+type Campaign struct {
+	mutex      sync.Mutex
+	recipients []*Recipient
+	pending    int
+	sending    bool
+	done       chan struct{}
+}
+
+func newCampaign() *Campaign {
+	return &Campaign{sending: true, done: make(chan struct{})}
+}
+
+// Get a copy of the current state of all recipients.
+func (self *Campaign) Recipients() []Recipient {
+	return self.filter(func(*Recipient) bool { return true })
+}
+
+// Get the recipients the message could not be delivered to.
+func (self *Campaign) Failed() []Recipient {
+	return self.filter(func(recipient *Recipient) bool { return recipient.Status == StatusFailed })
+}
+
+// Get a channel that is closed when the message was delivered or failed for all recipients.
+func (self *Campaign) Done() <-chan struct{} {
+	return self.done
+}
+
+func (self *Campaign) filter(accept func(*Recipient) bool) []Recipient {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	var result []Recipient
+	for _, recipient := range self.recipients {
+		if accept(recipient) {
+			result = append(result, *recipient)
+		}
+	}
+	return result
+}
+
+func (self *Campaign) add(recipient *Recipient) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.recipients = append(self.recipients, recipient)
+	if recipient.Status == StatusPending {
+		self.pending++
+	}
+}
+
+// Track the delivery of the recipient's message after the SendQueue sent it.
+func (self *Campaign) sent(bot *deltachat.Bot, accId deltachat.AccountId, recipient *Recipient, msgId deltachat.MsgId, err error) {
+	if err != nil {
+		self.resolve(recipient, StatusFailed, err.Error())
+		return
+	}
+	self.mutex.Lock()
+	recipient.MsgId = msgId
+	self.mutex.Unlock()
+	delivery, err := bot.TrackDelivery(context.Background(), accId, msgId)
+	if err != nil {
+		self.resolve(recipient, StatusFailed, err.Error())
+		return
+	}
+	go self.track(recipient, delivery)
+}
+
+func (self *Campaign) resolve(recipient *Recipient, status Status, errorText string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if recipient.Status != StatusPending {
+		return
+	}
+	recipient.Status = status
+	recipient.Error = errorText
+	self.pending--
+	self.checkDone()
+}
+
+// Resolve the recipient when its message is delivered or fails.
+func (self *Campaign) track(recipient *Recipient, delivery *deltachat.Delivery) {
+	<-delivery.Done()
+	err := delivery.Err()
+	if err == nil {
+		self.resolve(recipient, StatusDelivered, "")
+		return
+	}
+	errorText := err.Error()
+	var failedErr *deltachat.MsgFailedErr
+	if errors.As(err, &failedErr) && failedErr.Reason != "" {
+		errorText = failedErr.Reason
+	}
+	self.resolve(recipient, StatusFailed, errorText)
+}
+
+func (self *Campaign) finishSending() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.sending = false
+	self.checkDone()
+}
+
+func (self *Campaign) checkDone() {
+	if !self.sending && self.pending == 0 {
+		close(self.done)
+	}
+}
+
+// Broadcaster manages the subscribers of a named list.
+type Broadcaster struct {
+	// Command to subscribe, sent in the 1:1 chat with the bot, "/subscribe" by default.
+	SubscribeCommand string
+	// Command to unsubscribe, sent in the 1:1 chat with the bot, "/unsubscribe" by default.
+	UnsubscribeCommand string
+	// Text sent after subscribing, if empty nothing is sent.
+	SubscribedText string
+	// Text sent after unsubscribing, if empty nothing is sent.
+	UnsubscribedText string
+
+	name string
+}
+
+// Create a new Broadcaster for the list with the given name.
+// The name is used to persist the subscribers and as name of the broadcast list chat.
+func NewBroadcaster(name string) *Broadcaster {
+	return &Broadcaster{
+		SubscribeCommand:   "/subscribe",
+		UnsubscribeCommand: "/unsubscribe",
+		SubscribedText:     "You are now subscribed.",
+		UnsubscribedText:   "You are now unsubscribed.",
+		name:               name,
+	}
+}
+
+// Get the subscribers of the list in the given account.
+func (self *Broadcaster) Subscribers(bot *deltachat.Bot, accId deltachat.AccountId) ([]deltachat.ContactId, error) {
+	var ids []deltachat.ContactId
+	_, err := self.store(bot, accId).Get("subscribers", &ids)
+	return ids, err
+}
+
+// Replace the subscribers of the list in the given account.
+func (self *Broadcaster) SetSubscribers(bot *deltachat.Bot, accId deltachat.AccountId, ids []deltachat.ContactId) error {
+	ids = append([]deltachat.ContactId{}, ids...)
+	sortIds(ids)
+	return self.store(bot, accId).Set("subscribers", ids)
+}
+
+// Add the given contact to the subscribers, returns false if it was already subscribed.
+func (self *Broadcaster) Subscribe(bot *deltachat.Bot, accId deltachat.AccountId, contactId deltachat.ContactId) (bool, error) {
+	return self.update(bot, accId, func(ids map[deltachat.ContactId]bool) bool {
+		if ids[contactId] {
+			return false
+		}
+		ids[contactId] = true
+		return true
+	})
+}
+
+// Remove the given contact from the subscribers, returns false if it was not subscribed.
+func (self *Broadcaster) Unsubscribe(bot *deltachat.Bot, accId deltachat.AccountId, contactId deltachat.ContactId) (bool, error) {
+	return self.update(bot, accId, func(ids map[deltachat.ContactId]bool) bool {
+		if !ids[contactId] {
+			return false
+		}
+		delete(ids, contactId)
+		return true
+	})
+}
+
+// Get the broadcast list chat of the given account, creating it if needed,
+// and make the subscribers its members.
+// Messages sent to the returned chat are delivered to all subscribers at once,
+// use Send() to track the delivery per subscriber.
+func (self *Broadcaster) SyncList(bot *deltachat.Bot, accId deltachat.AccountId) (deltachat.ChatId, error) {
+	store := self.store(bot, accId)
+	var chatId deltachat.ChatId
+	found, err := store.Get("chat", &chatId)
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		if _, err = bot.Rpc.GetBasicChatInfo(accId, chatId); err != nil {
+			found = false // The chat was deleted
+		}
+	}
+	if !found {
+		if chatId, err = bot.Rpc.CreateBroadcastList(accId); err != nil {
+			return 0, err
+		}
+		if err = bot.Rpc.SetChatName(accId, chatId, self.name); err != nil {
+			return 0, err
+		}
+		if err = store.Set("chat", chatId); err != nil {
+			return 0, err
+		}
+	}
+
+	subscribers, err := self.Subscribers(bot, accId)
+	if err != nil {
+		return 0, err
+	}
+	_, err = groups.Sync(bot.Rpc, accId, chatId, subscribers)
+	return chatId, err
+}
+
+// Send the given message to every subscriber in their 1:1 chat with the bot.
+// The messages are pushed to the bot's SendQueue with deltachat.PriorityLow, so they are sent
+// respecting the queue's rate limits after any other pending message. Send returns once all messages
+// are queued or ctx is cancelled, delivery is tracked with Bot.TrackDelivery(), so the bot must be
+// running for the Campaign to finish. Returns a NoSendQueueErr if the bot has no SendQueue.
+func (self *Broadcaster) Send(ctx context.Context, bot *deltachat.Bot, accId deltachat.AccountId, data deltachat.MsgData) (*Campaign, error) {
+	queue := bot.SendQueue()
+	if queue == nil {
+		return nil, &NoSendQueueErr{}
+	}
+	subscribers, err := self.Subscribers(bot, accId)
+	if err != nil {
+		return nil, err
+	}
+	campaign := newCampaign()
+	defer campaign.finishSending()
+	for _, contactId := range subscribers {
+		if ctx.Err() != nil {
+			return campaign, ctx.Err()
+		}
+		recipient := &Recipient{ContactId: contactId}
+		campaign.add(recipient)
+		chatId, err := bot.Rpc.CreateChatByContactId(accId, contactId)
+		if err == nil {
+			_, err = queue.PushFunc(bot, accId, chatId, data, deltachat.PriorityLow, func(msgId deltachat.MsgId, err error) {
+				campaign.sent(bot, accId, recipient, msgId, err)
+			})
+		}
+		if err != nil {
+			campaign.resolve(recipient, StatusFailed, err.Error())
+		}
+	}
+	return campaign, nil
+}
+
+// Wrap the given NewMsgHandler so that subscribe/unsubscribe commands are processed
+// by the Broadcaster, other messages are passed to the given handler.
+// The returned handler is meant to be set with Bot.OnNewMsg().
+func (self *Broadcaster) Middleware(next deltachat.NewMsgHandler) deltachat.NewMsgHandler {
+	return func(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) {
+		handled, _ := self.HandleMsg(bot, accId, msgId)
+		if !handled && next != nil {
+			next(bot, accId, msgId)
+		}
+	}
+}
+
+// Process the given message if it is a subscribe/unsubscribe command sent in a 1:1 chat.
+// Returns true if the message was a command.
+func (self *Broadcaster) HandleMsg(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) (bool, error) {
+	msg, err := bot.Rpc.GetMessage(accId, msgId)
+	if err != nil {
+		return false, err
+	}
+	command := strings.TrimSpace(msg.Text)
+	isSubscribe := strings.EqualFold(command, self.SubscribeCommand)
+	if msg.FromId <= deltachat.ContactLastSpecial || (!isSubscribe && !strings.EqualFold(command, self.UnsubscribeCommand)) {
+		return false, nil
+	}
+	chat, err := bot.Rpc.GetBasicChatInfo(accId, msg.ChatId)
+	if err != nil || chat.ChatType != deltachat.ChatSingle {
+		return false, err
+	}
+
+	text := self.UnsubscribedText
+	if isSubscribe {
+		text = self.SubscribedText
+		_, err = self.Subscribe(bot, accId, msg.FromId)
+	} else {
+		_, err = self.Unsubscribe(bot, accId, msg.FromId)
+	}
+	if err == nil && text != "" {
+		_, err = bot.Rpc.MiscSendTextMessage(accId, msg.ChatId, text)
+	}
+	return true, err
+}
+
+func (self *Broadcaster) update(bot *deltachat.Bot, accId deltachat.AccountId, change func(map[deltachat.ContactId]bool) bool) (bool, error) {
+	store := self.store(bot, accId)
+	for {
+		var old []deltachat.ContactId
+		found, err := store.Get("subscribers", &old)
+		if err != nil {
+			return false, err
+		}
+		set := make(map[deltachat.ContactId]bool, len(old))
+		for _, id := range old {
+			set[id] = true
+		}
+		if !change(set) {
+			return false, nil
+		}
+		ids := make([]deltachat.ContactId, 0, len(set))
+		for id := range set {
+			ids = append(ids, id)
+		}
+		sortIds(ids)
+		var oldValue any
+		if found {
+			oldValue = old
+		}
+		if ok, err := store.CompareAndSet("subscribers", oldValue, ids); err != nil || ok {
+			return ok, err
+		}
+	}
+}
+
+func (self *Broadcaster) store(bot *deltachat.Bot, accId deltachat.AccountId) *deltachat.Store {
+	return bot.Store(accId).Namespace("broadcast").Namespace(self.name)
+}
+
+func sortIds(ids []deltachat.ContactId) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
@@ -0,0 +1,92 @@
+package broadcast
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaign(t *testing.T) {
+	t.Parallel()
+	campaign := newCampaign()
+	recipient1 := &Recipient{ContactId: 10, MsgId: 20}
+	recipient2 := &Recipient{ContactId: 11, MsgId: 21}
+	campaign.add(recipient1)
+	campaign.add(recipient2)
+	campaign.add(&Recipient{ContactId: 12, Status: StatusFailed, Error: "error"})
+	campaign.finishSending()
+
+	campaign.resolve(recipient1, StatusDelivered, "")
+	select {
+	case <-campaign.Done():
+		t.Fatal("campaign finished too early")
+	default:
+	}
+	campaign.resolve(recipient2, StatusFailed, "bounced")
+	campaign.resolve(recipient2, StatusDelivered, "")
+	<-campaign.Done()
+
+	assert.Len(t, campaign.Recipients(), 3)
+	failed := campaign.Failed()
+	require.Len(t, failed, 2)
+	assert.Equal(t, deltachat.ContactId(11), failed[0].ContactId)
+	assert.Equal(t, "bounced", failed[0].Error)
+	assert.Equal(t, "Failed", StatusFailed.String())
+	assert.Equal(t, "Status(7)", Status(7).String())
+}
+
+func TestBroadcaster_Send_noQueue(t *testing.T) {
+	t.Parallel()
+	_, err := NewBroadcaster("news").Send(context.Background(), deltachat.NewBot(nil), 1, deltachat.MsgData{Text: "news!"})
+	var queueErr *NoSendQueueErr
+	assert.ErrorAs(t, err, &queueErr)
+}
+
+func TestBroadcaster(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineBot(func(bot *deltachat.Bot, botAcc deltachat.AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *deltachat.Rpc, accId deltachat.AccountId) {
+			news := NewBroadcaster("news")
+			bot.OnNewMsg(news.Middleware(nil))
+			bot.SetSendQueue(deltachat.NewSendQueue(deltachat.RateLimit{}, deltachat.RateLimit{}))
+			go bot.Run() //nolint:errcheck
+			defer bot.Stop()
+
+			chatWithBot := acfactory.CreateChat(accRpc, accId, bot.Rpc, botAcc)
+			_, err := accRpc.MiscSendTextMessage(accId, chatWithBot, "/subscribe")
+			require.Nil(t, err)
+			assert.Equal(t, news.SubscribedText, acfactory.NextMsg(accRpc, accId).Text)
+
+			subscribers, err := news.Subscribers(bot, botAcc)
+			require.Nil(t, err)
+			require.Len(t, subscribers, 1)
+
+			chatId, err := news.SyncList(bot, botAcc)
+			require.Nil(t, err)
+			members, err := bot.Rpc.GetChatContacts(botAcc, chatId)
+			require.Nil(t, err)
+			assert.Contains(t, members, subscribers[0])
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			campaign, err := news.Send(ctx, bot, botAcc, deltachat.MsgData{Text: "news!"})
+			require.Nil(t, err)
+			assert.Equal(t, "news!", acfactory.NextMsg(accRpc, accId).Text)
+			<-campaign.Done()
+			recipients := campaign.Recipients()
+			require.Len(t, recipients, 1)
+			assert.Equal(t, StatusDelivered, recipients[0].Status)
+
+			_, err = accRpc.MiscSendTextMessage(accId, chatWithBot, "/unsubscribe")
+			require.Nil(t, err)
+			assert.Equal(t, news.UnsubscribedText, acfactory.NextMsg(accRpc, accId).Text)
+			subscribers, err = news.Subscribers(bot, botAcc)
+			require.Nil(t, err)
+			assert.Empty(t, subscribers)
+		})
+	})
+}
@@ -0,0 +1,201 @@
+package deltachat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Connectivity level of an account, see Rpc.GetConnectivity().
+type Connectivity uint
+
+const (
+	ConnectivityNotConnected Connectivity = 1000
+	ConnectivityConnecting   Connectivity = 2000
+	ConnectivityWorking      Connectivity = 3000
+	ConnectivityConnected    Connectivity = 4000
+)
+
+// Get the Connectivity level of a raw value returned by Rpc.GetConnectivity().
+// The core uses ranges of values, the value is rounded down to the start of its range.
+func NewConnectivity(value uint) Connectivity {
+	switch {
+	case value >= uint(ConnectivityConnected):
+		return ConnectivityConnected
+	case value >= uint(ConnectivityWorking):
+		return ConnectivityWorking
+	case value >= uint(ConnectivityConnecting):
+		return ConnectivityConnecting
+	default:
+		return ConnectivityNotConnected
+	}
+}
+
+func (self Connectivity) String() string {
+	switch NewConnectivity(uint(self)) {
+	case ConnectivityConnected:
+		return "Connected"
+	case ConnectivityWorking:
+		return "Working"
+	case ConnectivityConnecting:
+		return "Connecting"
+	default:
+		return "NotConnected"
+	}
+}
+
+// Get the current Connectivity level of the given account.
+func (self *Rpc) GetConnectivityLevel(accountId AccountId) (Connectivity, error) {
+	value, err := self.GetConnectivity(accountId)
+	return NewConnectivity(value), err
+}
+
+// A connectivity transition of an account, see ConnectivityWatcher.
+type ConnectivityChange struct {
+	AccountId AccountId
+	From      Connectivity
+	To        Connectivity
+	Duration  time.Duration // Time spent in the From level
+}
+
+func (self *ConnectivityChange) String() string {
+	return fmt.Sprintf("account %v: %v -> %v after %v", self.AccountId, self.From, self.To, self.Duration)
+}
+
+type connectivityState struct {
+	level   Connectivity
+	since   time.Time
+	backoff time.Duration
+	retry   time.Time
+}
+
+// ConnectivityWatcher reports the connectivity transitions of the bot accounts and
+// optionally asks the core to retry connecting when an account stays disconnected.
+// The zero value is a watcher without OnChange function.
+//
+// Typical usage:
+//
+//	watcher := deltachat.NewConnectivityWatcher(func(bot *deltachat.Bot, change *deltachat.ConnectivityChange) {
+//		log.Println(change)
+//	})
+//	watcher.RetryAfter = 30 * time.Second
+//	watcher.Register(bot)
+//	go watcher.Run(ctx, bot)
+type ConnectivityWatcher struct {
+	// Optional, called for every transition.
+	OnChange func(bot *Bot, change *ConnectivityChange)
+	// If greater than zero, Rpc.MaybeNetwork() is called when an account is not connected for this long.
+	// The time between retries is doubled after every retry, up to MaxBackoff.
+	RetryAfter time.Duration
+	// Maximum time between retries, 10 minutes if zero.
+	MaxBackoff time.Duration
+	// How often Run() checks the connectivity, 5 seconds if zero.
+	PollInterval time.Duration
+
+	mutex  sync.Mutex
+	states map[AccountId]*connectivityState
+	now    func() time.Time
+}
+
+// Create a new ConnectivityWatcher calling the given function for every transition.
+func NewConnectivityWatcher(onChange func(bot *Bot, change *ConnectivityChange)) *ConnectivityWatcher {
+	return &ConnectivityWatcher{OnChange: onChange}
+}
+
+// Set the watcher as the bot's EventHandler for EventConnectivityChanged.
+// This overrides any EventHandler previously set for that event with Bot.On(),
+// if you need your own handler, call HandleEvent() from it instead.
+func (self *ConnectivityWatcher) Register(bot *Bot) {
+	bot.On(EventConnectivityChanged{}, self.HandleEvent)
+}
+
+// EventHandler checking the account connectivity on EventConnectivityChanged, other events are ignored.
+func (self *ConnectivityWatcher) HandleEvent(bot *Bot, accId AccountId, event Event) {
+	if _, ok := event.(EventConnectivityChanged); ok {
+		self.Check(bot, accId) //nolint:errcheck
+	}
+}
+
+// Poll the connectivity of all accounts and retry connecting stuck accounts until ctx is done.
+// Polling is needed for retries, transitions are also detected by Register()/HandleEvent().
+func (self *ConnectivityWatcher) Run(ctx context.Context, bot *Bot) {
+	interval := self.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		accIds, _ := bot.Rpc.GetAllAccountIds()
+		for _, accId := range accIds {
+			self.Check(bot, accId) //nolint:errcheck
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check the connectivity of the given account, report the transition if it changed,
+// and call Rpc.MaybeNetwork() if the account is stuck disconnected. Returns the current level.
+func (self *ConnectivityWatcher) Check(bot *Bot, accId AccountId) (Connectivity, error) {
+	level, err := bot.Rpc.GetConnectivityLevel(accId)
+	if err != nil {
+		return level, err
+	}
+	change, retry := self.update(accId, level)
+	if change != nil && self.OnChange != nil {
+		self.OnChange(bot, change)
+	}
+	if retry {
+		err = bot.Rpc.MaybeNetwork()
+	}
+	return level, err
+}
+
+// Record the given level, returns the transition if the level changed and whether to retry connecting.
+func (self *ConnectivityWatcher) update(accId AccountId, level Connectivity) (*ConnectivityChange, bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.states == nil {
+		self.states = make(map[AccountId]*connectivityState)
+	}
+	if self.now == nil {
+		self.now = time.Now
+	}
+	now := self.now()
+	state, ok := self.states[accId]
+	if !ok {
+		state = &connectivityState{level: level, since: now}
+		self.states[accId] = state
+		self.resetRetry(state)
+		return nil, false
+	}
+
+	var change *ConnectivityChange
+	if state.level != level {
+		change = &ConnectivityChange{AccountId: accId, From: state.level, To: level, Duration: now.Sub(state.since)}
+		state.level = level
+		state.since = now
+		self.resetRetry(state)
+	}
+
+	if self.RetryAfter <= 0 || level != ConnectivityNotConnected || now.Before(state.retry) {
+		return change, false
+	}
+	maxBackoff := self.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Minute
+	}
+	state.backoff = min(state.backoff*2, maxBackoff)
+	state.retry = now.Add(state.backoff)
+	return change, true
+}
+
+func (self *ConnectivityWatcher) resetRetry(state *connectivityState) {
+	state.backoff = self.RetryAfter
+	state.retry = state.since.Add(self.RetryAfter)
+}
@@ -0,0 +1,77 @@
+package deltachat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectivity(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, ConnectivityNotConnected, NewConnectivity(0))
+	assert.Equal(t, ConnectivityConnecting, NewConnectivity(2500))
+	assert.Equal(t, ConnectivityWorking, NewConnectivity(3000))
+	assert.Equal(t, ConnectivityConnected, NewConnectivity(4200))
+	assert.Equal(t, "Working", Connectivity(3500).String())
+	assert.Equal(t, "NotConnected", ConnectivityNotConnected.String())
+}
+
+func TestConnectivityWatcher_update(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000, 0)
+	watcher := NewConnectivityWatcher(nil)
+	watcher.RetryAfter = 10 * time.Second
+	watcher.MaxBackoff = 30 * time.Second
+	watcher.now = func() time.Time { return now }
+
+	change, retry := watcher.update(1, ConnectivityConnected)
+	assert.Nil(t, change)
+	assert.False(t, retry)
+
+	now = now.Add(time.Minute)
+	change, retry = watcher.update(1, ConnectivityNotConnected)
+	require.NotNil(t, change)
+	assert.Equal(t, ConnectivityChange{AccountId: 1, From: ConnectivityConnected, To: ConnectivityNotConnected, Duration: time.Minute}, *change)
+	assert.False(t, retry)
+
+	now = now.Add(10 * time.Second)
+	_, retry = watcher.update(1, ConnectivityNotConnected)
+	assert.True(t, retry)
+	now = now.Add(10 * time.Second)
+	_, retry = watcher.update(1, ConnectivityNotConnected)
+	assert.False(t, retry) // Backoff doubled
+	now = now.Add(10 * time.Second)
+	_, retry = watcher.update(1, ConnectivityNotConnected)
+	assert.True(t, retry)
+	now = now.Add(30 * time.Second)
+	_, retry = watcher.update(1, ConnectivityNotConnected)
+	assert.True(t, retry) // Backoff limited by MaxBackoff
+
+	change, retry = watcher.update(1, ConnectivityConnecting)
+	require.NotNil(t, change)
+	assert.Equal(t, 60*time.Second, change.Duration)
+	assert.False(t, retry)
+}
+
+func TestConnectivityWatcher_zeroValue(t *testing.T) {
+	t.Parallel()
+	watcher := &ConnectivityWatcher{}
+	change, retry := watcher.update(1, ConnectivityConnected)
+	assert.Nil(t, change)
+	assert.False(t, retry)
+	change, _ = watcher.update(1, ConnectivityConnecting)
+	require.NotNil(t, change)
+	assert.Equal(t, ConnectivityConnecting, change.To)
+}
+
+func TestConnectivityWatcher_Check(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineBot(func(bot *Bot, botAcc AccountId) {
+		watcher := NewConnectivityWatcher(nil)
+		level, err := watcher.Check(bot, botAcc)
+		require.Nil(t, err)
+		assert.GreaterOrEqual(t, level, ConnectivityNotConnected)
+	})
+}
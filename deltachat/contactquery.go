@@ -0,0 +1,148 @@
+package deltachat
+
+import (
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+)
+
+// Default number of contacts fetched per page by ContactPager.
+const DefaultContactPageSize = 500
+
+// ContactQuery builds the flags and search text used to list contacts.
+//
+// Typical usage:
+//
+//	contacts, err := deltachat.NewContactQuery().VerifiedOnly().Text("alice").Contacts(rpc, accId)
+type ContactQuery struct {
+	flags ContactFlag
+	query option.Option[string]
+}
+
+// Create a new ContactQuery matching all known and unblocked contacts.
+func NewContactQuery() *ContactQuery {
+	return &ContactQuery{}
+}
+
+// Only list verified contacts.
+func (self *ContactQuery) VerifiedOnly() *ContactQuery {
+	self.flags |= ContactFlagVerifiedOnly
+	return self
+}
+
+// Include the self-contact (ContactSelf) in the results.
+func (self *ContactQuery) AddSelf() *ContactQuery {
+	self.flags |= ContactFlagAddSelf
+	return self
+}
+
+// Only list contacts whose name or address contains the given text.
+// An empty text removes the filter.
+func (self *ContactQuery) Text(query string) *ContactQuery {
+	if query == "" {
+		self.query = option.None[string]()
+	} else {
+		self.query = option.Some(query)
+	}
+	return self
+}
+
+// Get the list flags of this query.
+func (self *ContactQuery) Flags() ContactFlag {
+	return self.flags
+}
+
+// Get the search text of this query, if any.
+func (self *ContactQuery) Query() option.Option[string] {
+	return self.query
+}
+
+// Get the IDs of the contacts matching this query.
+func (self *ContactQuery) Ids(rpc *Rpc, accId AccountId) ([]ContactId, error) {
+	return rpc.GetContactIds(accId, uint(self.flags), self.query)
+}
+
+// Get the snapshots of all the contacts matching this query in a single call.
+// For accounts with a big number of contacts use Pages() instead.
+func (self *ContactQuery) Contacts(rpc *Rpc, accId AccountId) ([]*ContactSnapshot, error) {
+	return rpc.GetContacts(accId, uint(self.flags), self.query)
+}
+
+// Get a ContactPager to fetch the contacts matching this query in pages of the given size.
+// If pageSize is zero or negative DefaultContactPageSize is used.
+func (self *ContactQuery) Pages(rpc *Rpc, accId AccountId, pageSize int) (*ContactPager, error) {
+	ids, err := self.Ids(rpc, accId)
+	if err != nil {
+		return nil, err
+	}
+	return NewContactPager(rpc, accId, ids, pageSize), nil
+}
+
+// ContactPager fetches the snapshots of a list of contacts in pages,
+// avoiding to load tens of thousands of contacts in a single call.
+type ContactPager struct {
+	rpc      *Rpc
+	accId    AccountId
+	ids      []ContactId
+	pageSize int
+	offset   int
+}
+
+// Create a new ContactPager over the given contact IDs.
+// If pageSize is zero or negative DefaultContactPageSize is used.
+func NewContactPager(rpc *Rpc, accId AccountId, ids []ContactId, pageSize int) *ContactPager {
+	if pageSize <= 0 {
+		pageSize = DefaultContactPageSize
+	}
+	return &ContactPager{rpc: rpc, accId: accId, ids: ids, pageSize: pageSize}
+}
+
+// Get the total number of contacts.
+func (self *ContactPager) Len() int {
+	return len(self.ids)
+}
+
+// Get the total number of pages.
+func (self *ContactPager) PageCount() int {
+	return (len(self.ids) + self.pageSize - 1) / self.pageSize
+}
+
+// Return true if there are pages left to be fetched with Next().
+func (self *ContactPager) HasNext() bool {
+	return self.offset < len(self.ids)
+}
+
+// Fetch the next page of contacts. An empty page is returned if there are no more pages.
+func (self *ContactPager) Next() ([]*ContactSnapshot, error) {
+	page, err := self.Page(self.offset / self.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	self.offset = min(self.offset+self.pageSize, len(self.ids))
+	return page, nil
+}
+
+// Fetch the page with the given index, starting at zero.
+// The contacts are returned in the same order as their IDs.
+func (self *ContactPager) Page(index int) ([]*ContactSnapshot, error) {
+	start := index * self.pageSize
+	if index < 0 || start >= len(self.ids) {
+		return []*ContactSnapshot{}, nil
+	}
+	end := min(start+self.pageSize, len(self.ids))
+	ids := self.ids[start:end]
+	contactsMap, err := self.rpc.GetContactsByIds(self.accId, ids)
+	if err != nil {
+		return nil, err
+	}
+	contacts := make([]*ContactSnapshot, 0, len(ids))
+	for _, id := range ids {
+		if contact, ok := contactsMap[id]; ok {
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts, nil
+}
+
+// Rewind the pager so the next call to Next() returns the first page.
+func (self *ContactPager) Reset() {
+	self.offset = 0
+}
@@ -0,0 +1,82 @@
+package deltachat
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactQuery_Flags(t *testing.T) {
+	t.Parallel()
+	query := NewContactQuery()
+	assert.Equal(t, ContactFlag(0), query.Flags())
+	assert.True(t, query.Query().IsNone())
+
+	query.VerifiedOnly().AddSelf().Text("test")
+	assert.Equal(t, ContactFlagVerifiedOnly|ContactFlagAddSelf, query.Flags())
+	assert.Equal(t, "test", query.Query().Unwrap())
+
+	query.Text("")
+	assert.True(t, query.Query().IsNone())
+}
+
+func TestContactQuery_Contacts(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		contactId, err := rpc.CreateContact(accId, "null@localhost", "test")
+		require.Nil(t, err)
+
+		contacts, err := NewContactQuery().Text("test").Contacts(rpc, accId)
+		require.Nil(t, err)
+		require.Len(t, contacts, 1)
+		assert.Equal(t, contactId, contacts[0].Id)
+
+		contacts, err = NewContactQuery().Text("unknown").Contacts(rpc, accId)
+		require.Nil(t, err)
+		assert.Empty(t, contacts)
+
+		ids, err := NewContactQuery().AddSelf().Ids(rpc, accId)
+		require.Nil(t, err)
+		assert.Contains(t, ids, ContactSelf)
+		assert.Contains(t, ids, contactId)
+
+		contactsMap, err := rpc.GetContactsByIds(accId, ids)
+		require.Nil(t, err)
+		assert.Equal(t, contactId, contactsMap[contactId].Id)
+	})
+}
+
+func TestContactPager(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		for i := 0; i < 5; i++ {
+			_, err := rpc.CreateContact(accId, fmt.Sprintf("null%v@localhost", i), "")
+			require.Nil(t, err)
+		}
+
+		pager, err := NewContactQuery().Pages(rpc, accId, 2)
+		require.Nil(t, err)
+		assert.Equal(t, 5, pager.Len())
+		assert.Equal(t, 3, pager.PageCount())
+
+		var contacts []*ContactSnapshot
+		for pager.HasNext() {
+			page, err := pager.Next()
+			require.Nil(t, err)
+			contacts = append(contacts, page...)
+		}
+		assert.Len(t, contacts, 5)
+
+		page, err := pager.Next()
+		require.Nil(t, err)
+		assert.Empty(t, page)
+
+		pager.Reset()
+		page, err = pager.Page(2)
+		require.Nil(t, err)
+		assert.Len(t, page, 1)
+		assert.True(t, pager.HasNext())
+	})
+}
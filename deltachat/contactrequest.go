@@ -0,0 +1,289 @@
+package deltachat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// What to do with a message received in a contact request chat, see ContactRequestPolicy.
+type ContactRequestDecision int
+
+const (
+	// Leave the chat as contact request and process the message as usual.
+	ContactRequestPass ContactRequestDecision = iota
+	// Accept the chat and process the message.
+	ContactRequestAccept
+	// Block the chat, the message is not processed.
+	ContactRequestBlock
+	// Leave the chat as contact request, the message is not processed.
+	ContactRequestHold
+)
+
+func (self ContactRequestDecision) String() string {
+	switch self {
+	case ContactRequestPass:
+		return "Pass"
+	case ContactRequestAccept:
+		return "Accept"
+	case ContactRequestBlock:
+		return "Block"
+	case ContactRequestHold:
+		return "Hold"
+	default:
+		return fmt.Sprintf("ContactRequestDecision(%d)", int(self))
+	}
+}
+
+// ContactRequestPolicy decides what to do with the messages received in contact request chats,
+// see Bot.SetContactRequestPolicy().
+type ContactRequestPolicy interface {
+	Decide(bot *Bot, accId AccountId, msg *MsgSnapshot) (ContactRequestDecision, error)
+}
+
+// ContactRequestPolicyFunc is a function implementing ContactRequestPolicy.
+type ContactRequestPolicyFunc func(bot *Bot, accId AccountId, msg *MsgSnapshot) (ContactRequestDecision, error)
+
+func (self ContactRequestPolicyFunc) Decide(bot *Bot, accId AccountId, msg *MsgSnapshot) (ContactRequestDecision, error) {
+	return self(bot, accId, msg)
+}
+
+// Policy accepting all contact requests.
+type AcceptAllPolicy struct{}
+
+func (self AcceptAllPolicy) Decide(bot *Bot, accId AccountId, msg *MsgSnapshot) (ContactRequestDecision, error) {
+	return ContactRequestAccept, nil
+}
+
+// Policy accepting contact requests from verified contacts only.
+type VerifiedOnlyPolicy struct {
+	// Decision for contacts that are not verified, the zero value passes the message without accepting the chat.
+	Otherwise ContactRequestDecision
+}
+
+func (self VerifiedOnlyPolicy) Decide(bot *Bot, accId AccountId, msg *MsgSnapshot) (ContactRequestDecision, error) {
+	sender, err := msgSender(bot, accId, msg)
+	if err != nil {
+		return ContactRequestHold, err
+	}
+	if sender.IsVerified {
+		return ContactRequestAccept, nil
+	}
+	return self.Otherwise, nil
+}
+
+// Policy accepting contact requests from the given email domains.
+type DomainAllowListPolicy struct {
+	Domains []string
+	// Decision for contacts from other domains, the zero value passes the message without accepting the chat.
+	Otherwise ContactRequestDecision
+}
+
+func (self DomainAllowListPolicy) Decide(bot *Bot, accId AccountId, msg *MsgSnapshot) (ContactRequestDecision, error) {
+	sender, err := msgSender(bot, accId, msg)
+	if err != nil {
+		return ContactRequestHold, err
+	}
+	if index := strings.LastIndex(sender.Address, "@"); index >= 0 {
+		domain := sender.Address[index+1:]
+		for _, allowed := range self.Domains {
+			if strings.EqualFold(domain, allowed) {
+				return ContactRequestAccept, nil
+			}
+		}
+	}
+	return self.Otherwise, nil
+}
+
+// NoAdminChatErr is returned by AdminApprovalPolicy if the account has no admin chat to ask for approval.
+type NoAdminChatErr struct {
+	AccountId AccountId
+}
+
+func (self *NoAdminChatErr) Error() string {
+	return fmt.Sprintf("account %v has no admin chat", self.AccountId)
+}
+
+// Policy holding contact requests until an administrator approves or rejects them.
+//
+// A notification is sent to the account's admin chat for every new contact request,
+// administrators answer with "/accept <chat ID>" or "/reject <chat ID>" in the admin chat,
+// the commands only apply to chats with held contact requests.
+// The approval commands are processed by the handler returned by Middleware().
+// The held messages are persisted with Bot.Store(), so they survive restarts.
+// If the account has no admin chat, a NoAdminChatErr is returned and the message is not processed.
+type AdminApprovalPolicy struct {
+	// Admin chat of each account, if an account is missing, Bot.AdminChat() is used.
+	AdminChats map[AccountId]ChatId
+
+	mutex sync.Mutex
+}
+
+func (self *AdminApprovalPolicy) Decide(bot *Bot, accId AccountId, msg *MsgSnapshot) (ContactRequestDecision, error) {
+	adminChat, ok := self.adminChat(bot, accId)
+	if !ok {
+		return ContactRequestHold, &NoAdminChatErr{AccountId: accId}
+	}
+
+	isNew, err := self.hold(bot, accId, msg.ChatId, msg.Id)
+	if err != nil {
+		return ContactRequestHold, err
+	}
+	if isNew {
+		sender, err := msgSender(bot, accId, msg)
+		if err != nil {
+			return ContactRequestHold, err
+		}
+		text := fmt.Sprintf("Contact request from %v <%v>:\n\n%v\n\nReply /accept %v or /reject %v",
+			sender.DisplayName, sender.Address, msg.Text, msg.ChatId, msg.ChatId)
+		if _, err = bot.Rpc.MiscSendTextMessage(accId, adminChat, text); err != nil {
+			return ContactRequestHold, err
+		}
+	}
+	return ContactRequestHold, nil
+}
+
+// Wrap the given NewMsgHandler so that approval commands sent in the admin chat are processed,
+// other messages are passed to the given handler.
+// When a contact request is accepted, the held messages are passed to the given handler.
+// The returned handler is meant to be set with Bot.OnNewMsg().
+func (self *AdminApprovalPolicy) Middleware(next NewMsgHandler) NewMsgHandler {
+	return func(bot *Bot, accId AccountId, msgId MsgId) {
+		msg, err := bot.Rpc.GetMessage(accId, msgId)
+		adminChat, ok := self.adminChat(bot, accId)
+		if err != nil || !ok || msg.ChatId != adminChat || msg.FromId <= ContactLastSpecial || !self.handleCommand(bot, accId, msg, next) {
+			if next != nil {
+				next(bot, accId, msgId)
+			}
+		}
+	}
+}
+
+func (self *AdminApprovalPolicy) handleCommand(bot *Bot, accId AccountId, msg *MsgSnapshot, next NewMsgHandler) bool {
+	args := strings.Fields(msg.Text)
+	if len(args) != 2 || (args[0] != "/accept" && args[0] != "/reject") {
+		return false
+	}
+	id, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		return false
+	}
+	chatId := ChatId(id)
+
+	accept := args[0] == "/accept"
+	held, err := self.release(bot, accId, chatId, func() error {
+		if accept {
+			return bot.Rpc.AcceptChat(accId, chatId)
+		}
+		return bot.Rpc.BlockChat(accId, chatId)
+	})
+	reply := "Rejected."
+	if accept {
+		reply = "Accepted."
+	}
+	if err != nil {
+		reply = "Error: " + err.Error()
+	} else if len(held) == 0 {
+		reply = fmt.Sprintf("Nothing pending for chat %v.", chatId)
+	}
+	bot.Rpc.MiscSendTextMessage(accId, msg.ChatId, reply) //nolint:errcheck
+	if err == nil && accept && next != nil {
+		for _, msgId := range held {
+			next(bot, accId, msgId)
+		}
+	}
+	return true
+}
+
+// Add a message to the held messages of the given chat, returns true if it is the first held message.
+func (self *AdminApprovalPolicy) hold(bot *Bot, accId AccountId, chatId ChatId, msgId MsgId) (bool, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	store := heldMsgsStore(bot, accId)
+	key := fmt.Sprint(chatId)
+	var held []MsgId
+	if _, err := store.Get(key, &held); err != nil {
+		return false, err
+	}
+	return len(held) == 0, store.Set(key, append(held, msgId))
+}
+
+// Call apply if the given chat has held messages, and if it succeeds remove and get the held messages.
+// If there are no held messages, apply is not called and nothing is returned.
+func (self *AdminApprovalPolicy) release(bot *Bot, accId AccountId, chatId ChatId, apply func() error) ([]MsgId, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	store := heldMsgsStore(bot, accId)
+	key := fmt.Sprint(chatId)
+	var held []MsgId
+	if _, err := store.Get(key, &held); err != nil || len(held) == 0 {
+		return nil, err
+	}
+	if err := apply(); err != nil {
+		return nil, err
+	}
+	return held, store.Delete(key)
+}
+
+func heldMsgsStore(bot *Bot, accId AccountId) *Store {
+	return bot.Store(accId).Namespace("contact_requests")
+}
+
+func (self *AdminApprovalPolicy) adminChat(bot *Bot, accId AccountId) (ChatId, bool) {
+	if chatId, ok := self.AdminChats[accId]; ok {
+		return chatId, true
+	}
+	return bot.AdminChat(accId)
+}
+
+// Set the policy applied to messages received in contact request chats before they are passed
+// to the NewMsgHandler. A nil policy (the default) passes all messages.
+// If the policy returns an error, the message is not processed and the error is logged,
+// see the /logs admin command in Bot.SetAdminChat().
+func (self *Bot) SetContactRequestPolicy(policy ContactRequestPolicy) {
+	self.contactRequestPolicy = policy
+}
+
+// Apply the contact request policy to the given message, returns true if the message should be processed.
+func (self *Bot) applyContactRequestPolicy(accId AccountId, msgId MsgId) bool {
+	if self.contactRequestPolicy == nil {
+		return true
+	}
+	msg, err := self.Rpc.GetMessage(accId, msgId)
+	if err != nil {
+		return true
+	}
+	chat, err := self.Rpc.GetBasicChatInfo(accId, msg.ChatId)
+	if err != nil || !chat.IsContactRequest {
+		return true
+	}
+	decision, err := self.contactRequestPolicy.Decide(self, accId, msg)
+	if err != nil {
+		self.logError(accId, fmt.Errorf("contact request policy, message %v: %w", msgId, err))
+		return false
+	}
+	switch decision {
+	case ContactRequestAccept:
+		if err = self.Rpc.AcceptChat(accId, msg.ChatId); err != nil {
+			self.logError(accId, fmt.Errorf("accepting chat %v: %w", msg.ChatId, err))
+		}
+		return err == nil
+	case ContactRequestBlock:
+		if err = self.Rpc.BlockChat(accId, msg.ChatId); err != nil {
+			self.logError(accId, fmt.Errorf("blocking chat %v: %w", msg.ChatId, err))
+		}
+		return false
+	case ContactRequestHold:
+		return false
+	default:
+		return true
+	}
+}
+
+func msgSender(bot *Bot, accId AccountId, msg *MsgSnapshot) (*ContactSnapshot, error) {
+	if msg.Sender != nil {
+		return msg.Sender, nil
+	}
+	return bot.Rpc.GetContact(accId, msg.FromId)
+}
@@ -0,0 +1,92 @@
+package deltachat
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactRequestPolicies(t *testing.T) {
+	t.Parallel()
+	msg := &MsgSnapshot{Sender: &ContactSnapshot{Address: "alice@Example.org", IsVerified: false}}
+
+	decision, err := AcceptAllPolicy{}.Decide(nil, 1, msg)
+	require.Nil(t, err)
+	assert.Equal(t, ContactRequestAccept, decision)
+
+	policy := DomainAllowListPolicy{Domains: []string{"example.org"}, Otherwise: ContactRequestBlock}
+	decision, err = policy.Decide(nil, 1, msg)
+	require.Nil(t, err)
+	assert.Equal(t, ContactRequestAccept, decision)
+	policy.Domains = []string{"example.net"}
+	decision, _ = policy.Decide(nil, 1, msg)
+	assert.Equal(t, ContactRequestBlock, decision)
+
+	decision, err = VerifiedOnlyPolicy{Otherwise: ContactRequestHold}.Decide(nil, 1, msg)
+	require.Nil(t, err)
+	assert.Equal(t, ContactRequestHold, decision)
+	msg.Sender.IsVerified = true
+	decision, _ = VerifiedOnlyPolicy{}.Decide(nil, 1, msg)
+	assert.Equal(t, ContactRequestAccept, decision)
+
+	assert.Equal(t, "Hold", ContactRequestHold.String())
+}
+
+func TestAdminApprovalPolicy_held(t *testing.T) {
+	t.Parallel()
+	dir := acfactory.MkdirTemp()
+	defer os.RemoveAll(dir)
+	bot := NewBot(nil)
+	bot.stores = map[AccountId]*Store{1: NewStore(&FileStoreBackend{Path: filepath.Join(dir, "store.json")})}
+	policy := &AdminApprovalPolicy{}
+
+	_, err := policy.Decide(bot, 1, &MsgSnapshot{Id: 20, ChatId: 10})
+	var noChatErr *NoAdminChatErr
+	assert.ErrorAs(t, err, &noChatErr)
+
+	isNew, err := policy.hold(bot, 1, 10, 20)
+	require.Nil(t, err)
+	assert.True(t, isNew)
+	isNew, err = (&AdminApprovalPolicy{}).hold(bot, 1, 10, 21)
+	require.Nil(t, err)
+	assert.False(t, isNew)
+
+	// the messages are kept if accepting/rejecting fails
+	_, err = policy.release(bot, 1, 10, func() error { return errors.New("rpc failed") })
+	assert.EqualError(t, err, "rpc failed")
+	applied := 0
+	apply := func() error { applied++; return nil }
+	held, err := policy.release(bot, 1, 10, apply)
+	require.Nil(t, err)
+	assert.Equal(t, []MsgId{20, 21}, held)
+	held, err = policy.release(bot, 1, 10, apply)
+	require.Nil(t, err)
+	assert.Empty(t, held)
+	assert.Equal(t, 1, applied) // not called if nothing is pending
+}
+
+func TestBot_SetContactRequestPolicy(t *testing.T) {
+	t.Parallel()
+	acfactory.WithRunningBot(func(bot *Bot, botAcc AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *Rpc, accId AccountId) {
+			received := make(chan *MsgSnapshot, 1)
+			bot.SetContactRequestPolicy(AcceptAllPolicy{})
+			bot.OnNewMsg(func(bot *Bot, botAcc AccountId, msgId MsgId) {
+				msg, _ := bot.Rpc.GetMessage(botAcc, msgId)
+				received <- msg
+			})
+
+			chatWithBot := acfactory.CreateChat(accRpc, accId, bot.Rpc, botAcc)
+			_, err := accRpc.MiscSendTextMessage(accId, chatWithBot, "hi")
+			require.Nil(t, err)
+			msg := <-received
+			chat, err := bot.Rpc.GetBasicChatInfo(botAcc, msg.ChatId)
+			require.Nil(t, err)
+			assert.False(t, chat.IsContactRequest)
+		})
+	})
+}
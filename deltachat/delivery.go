@@ -0,0 +1,306 @@
+package deltachat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Outcome of an outgoing message, see Delivery.
+type DeliveryStatus int
+
+const (
+	DeliveryPending DeliveryStatus = iota
+	DeliveryDelivered
+	DeliveryRead
+	DeliveryFailed
+)
+
+func (self DeliveryStatus) String() string {
+	switch self {
+	case DeliveryPending:
+		return "Pending"
+	case DeliveryDelivered:
+		return "Delivered"
+	case DeliveryRead:
+		return "Read"
+	case DeliveryFailed:
+		return "Failed"
+	default:
+		return fmt.Sprintf("DeliveryStatus(%d)", int(self))
+	}
+}
+
+// MsgFailedErr is the error of a Delivery whose message could not be sent.
+type MsgFailedErr struct {
+	MsgId  MsgId
+	Reason string // MsgSnapshot.Error of the failed message
+}
+
+func (self *MsgFailedErr) Error() string {
+	if self.Reason == "" {
+		return fmt.Sprintf("message %v failed", self.MsgId)
+	}
+	return fmt.Sprintf("message %v failed: %v", self.MsgId, self.Reason)
+}
+
+// Delivery is a future resolved when an outgoing message is delivered, read or fails.
+// Deliveries are resolved by the bot event loop, so the Bot must be running (see Bot.Run()).
+// A delivery whose message never gets a delivery event stays pending until it is cancelled,
+// see Delivery.Cancel() and the ctx of Bot.TrackDelivery().
+type Delivery struct {
+	AccountId AccountId
+	MsgId     MsgId
+
+	tracker *deliveryTracker
+	mutex   sync.Mutex
+	status  DeliveryStatus
+	err     error
+	stop    func() bool
+	done    chan struct{}
+}
+
+func newDelivery(tracker *deliveryTracker, accId AccountId, msgId MsgId) *Delivery {
+	return &Delivery{AccountId: accId, MsgId: msgId, tracker: tracker, done: make(chan struct{})}
+}
+
+// Get a channel that is closed when the delivery is resolved or cancelled.
+func (self *Delivery) Done() <-chan struct{} {
+	return self.done
+}
+
+// Get the current status of the delivery.
+func (self *Delivery) Status() DeliveryStatus {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.status
+}
+
+// Get the error of a failed delivery, the error is a MsgFailedErr.
+// If the delivery was cancelled, the error is context.Canceled or the error of the cancelled context.
+func (self *Delivery) Err() error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.err
+}
+
+// Wait until the delivery is resolved or ctx is done.
+// The delivery is still tracked if ctx is done, call Cancel() to stop tracking it.
+func (self *Delivery) Wait(ctx context.Context) (DeliveryStatus, error) {
+	select {
+	case <-self.done:
+		return self.Status(), self.Err()
+	case <-ctx.Done():
+		return DeliveryPending, ctx.Err()
+	}
+}
+
+// Stop tracking the delivery. If it is not resolved yet, it is resolved with status DeliveryPending
+// and error context.Canceled.
+func (self *Delivery) Cancel() {
+	self.cancel(context.Canceled)
+}
+
+func (self *Delivery) cancel(err error) {
+	if self.tracker != nil {
+		self.tracker.remove(self)
+	}
+	self.resolve(DeliveryPending, err)
+}
+
+func (self *Delivery) resolve(status DeliveryStatus, err error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	select {
+	case <-self.done:
+		return
+	default:
+	}
+	self.status = status
+	self.err = err
+	if self.stop != nil {
+		self.stop()
+	}
+	close(self.done)
+}
+
+// Cancel the delivery when ctx is done.
+func (self *Delivery) cancelOnDone(ctx context.Context) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	select {
+	case <-self.done:
+		return
+	default:
+	}
+	self.stop = context.AfterFunc(ctx, func() { self.cancel(ctx.Err()) })
+}
+
+type deliveryKey struct {
+	accId AccountId
+	msgId MsgId
+}
+
+// Maximum number of delivery events buffered while messages are sent by Bot.SendMsgWithDelivery().
+const maxEarlyDeliveryEvents = 100
+
+// Keeps the pending deliveries of a Bot.
+type deliveryTracker struct {
+	mutex   sync.Mutex
+	pending map[deliveryKey][]*Delivery
+	// Number of ongoing Bot.SendMsgWithDelivery() calls.
+	sending int
+	// Events of untracked messages received while sending, the message ID is not known before
+	// the SendMsg call returns and the message can be delivered before that.
+	early map[deliveryKey]DeliveryStatus
+}
+
+func (self *deliveryTracker) add(delivery *Delivery) {
+	if self.pending == nil {
+		self.pending = make(map[deliveryKey][]*Delivery)
+	}
+	key := deliveryKey{delivery.AccountId, delivery.MsgId}
+	self.pending[key] = append(self.pending[key], delivery)
+}
+
+func (self *deliveryTracker) remove(delivery *Delivery) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	key := deliveryKey{delivery.AccountId, delivery.MsgId}
+	deliveries := self.pending[key]
+	for i, item := range deliveries {
+		if item == delivery {
+			deliveries = append(deliveries[:i], deliveries[i+1:]...)
+			break
+		}
+	}
+	if len(deliveries) == 0 {
+		delete(self.pending, key)
+	} else {
+		self.pending[key] = deliveries
+	}
+}
+
+func (self *deliveryTracker) onEvent(rpc *Rpc, accId AccountId, event Event) {
+	var msgId MsgId
+	var status DeliveryStatus
+	switch ev := event.(type) {
+	case EventMsgDelivered:
+		msgId, status = ev.MsgId, DeliveryDelivered
+	case EventMsgRead:
+		msgId, status = ev.MsgId, DeliveryRead
+	case EventMsgFailed:
+		msgId, status = ev.MsgId, DeliveryFailed
+	default:
+		return
+	}
+
+	self.mutex.Lock()
+	key := deliveryKey{accId, msgId}
+	deliveries := self.pending[key]
+	delete(self.pending, key)
+	if len(deliveries) == 0 && self.sending > 0 && len(self.early) < maxEarlyDeliveryEvents {
+		if self.early == nil {
+			self.early = make(map[deliveryKey]DeliveryStatus)
+		}
+		self.early[key] = status
+	}
+	self.mutex.Unlock()
+	if len(deliveries) == 0 {
+		return
+	}
+
+	err := deliveryErr(rpc, accId, msgId, status)
+	for _, delivery := range deliveries {
+		delivery.resolve(status, err)
+	}
+}
+
+// Get the error of a delivery with the given status, a MsgFailedErr if the message failed.
+func deliveryErr(rpc *Rpc, accId AccountId, msgId MsgId, status DeliveryStatus) error {
+	if status != DeliveryFailed {
+		return nil
+	}
+	failedErr := &MsgFailedErr{MsgId: msgId}
+	if msg, err := rpc.GetMessage(accId, msgId); err == nil {
+		failedErr.Reason = msg.Error
+	}
+	return failedErr
+}
+
+// Get a Delivery for the given outgoing message, the delivery is cancelled when ctx is done.
+// If the message was already delivered, read or failed, the returned Delivery is already resolved.
+func (self *Bot) TrackDelivery(ctx context.Context, accId AccountId, msgId MsgId) (*Delivery, error) {
+	delivery := newDelivery(&self.deliveries, accId, msgId)
+	self.deliveries.mutex.Lock()
+	self.deliveries.add(delivery)
+	self.deliveries.mutex.Unlock()
+
+	msg, err := self.Rpc.GetMessage(accId, msgId)
+	if err != nil {
+		self.deliveries.remove(delivery)
+		return nil, err
+	}
+	switch msg.State {
+	case MsgStateOutDelivered:
+		delivery.resolve(DeliveryDelivered, nil)
+	case MsgStateOutMdnRcvd:
+		delivery.resolve(DeliveryRead, nil)
+	case MsgStateOutFailed:
+		delivery.resolve(DeliveryFailed, &MsgFailedErr{MsgId: msgId, Reason: msg.Error})
+	default:
+		delivery.cancelOnDone(ctx)
+		return delivery, nil
+	}
+	self.deliveries.remove(delivery)
+	return delivery, nil
+}
+
+// Send a message and get a Delivery to track its outcome, the delivery is cancelled when ctx is done.
+func (self *Bot) SendMsgWithDelivery(ctx context.Context, accId AccountId, chatId ChatId, data MsgData) (*Delivery, error) {
+	tracker := &self.deliveries
+	tracker.mutex.Lock()
+	tracker.sending++
+	tracker.mutex.Unlock()
+
+	msgId, err := self.Rpc.SendMsg(accId, chatId, data)
+
+	tracker.mutex.Lock()
+	tracker.sending--
+	key := deliveryKey{accId, msgId}
+	status, early := tracker.early[key]
+	delete(tracker.early, key)
+	if tracker.sending == 0 {
+		tracker.early = nil
+	}
+	delivery := newDelivery(tracker, accId, msgId)
+	if err == nil && !early {
+		tracker.add(delivery)
+	}
+	tracker.mutex.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	if early {
+		delivery.resolve(status, deliveryErr(self.Rpc, accId, msgId, status))
+	} else {
+		delivery.cancelOnDone(ctx)
+	}
+	return delivery, nil
+}
+
+// Send a message and wait until it is delivered, read or fails, or until ctx is done.
+// If the message fails, a MsgFailedErr is returned. The Bot must be running (see Bot.Run()),
+// and this method must not be called from an event handler since it would block the event loop.
+func (self *Bot) SendMsgAndWait(ctx context.Context, accId AccountId, chatId ChatId, data MsgData) (MsgId, DeliveryStatus, error) {
+	delivery, err := self.SendMsgWithDelivery(ctx, accId, chatId, data)
+	if err != nil {
+		return 0, DeliveryPending, err
+	}
+	status, err := delivery.Wait(ctx)
+	if status == DeliveryPending {
+		delivery.Cancel()
+	}
+	return delivery.MsgId, status, err
+}
@@ -0,0 +1,98 @@
+package deltachat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelivery(t *testing.T) {
+	t.Parallel()
+	delivery := newDelivery(nil, 1, 10)
+	assert.Equal(t, DeliveryPending, delivery.Status())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	status, err := delivery.Wait(ctx)
+	assert.Equal(t, DeliveryPending, status)
+	assert.Equal(t, context.Canceled, err)
+
+	delivery.resolve(DeliveryFailed, &MsgFailedErr{MsgId: 10, Reason: "bounced"})
+	delivery.resolve(DeliveryDelivered, nil)
+	status, err = delivery.Wait(context.Background())
+	assert.Equal(t, DeliveryFailed, status)
+	assert.EqualError(t, err, "message 10 failed: bounced")
+	assert.Equal(t, "Failed", status.String())
+}
+
+func TestDeliveryTracker(t *testing.T) {
+	t.Parallel()
+	tracker := &deliveryTracker{}
+	delivery := newDelivery(tracker, 1, 10)
+	other := newDelivery(tracker, 2, 10)
+	tracker.add(delivery)
+	tracker.add(other)
+
+	tracker.onEvent(nil, 1, EventMsgRead{ChatId: 5, MsgId: 11})
+	assert.Equal(t, DeliveryPending, delivery.Status())
+	tracker.onEvent(nil, 1, EventMsgDelivered{ChatId: 5, MsgId: 10})
+	assert.Equal(t, DeliveryDelivered, delivery.Status())
+	assert.Equal(t, DeliveryPending, other.Status())
+
+	other.Cancel()
+	assert.Empty(t, tracker.pending)
+	<-other.Done()
+	assert.Equal(t, DeliveryPending, other.Status())
+	assert.Equal(t, context.Canceled, other.Err())
+
+	// events of untracked messages are only buffered while sending
+	tracker.onEvent(nil, 1, EventMsgDelivered{ChatId: 5, MsgId: 12})
+	assert.Empty(t, tracker.early)
+	tracker.sending = 1
+	tracker.onEvent(nil, 1, EventMsgDelivered{ChatId: 5, MsgId: 12})
+	assert.Equal(t, map[deliveryKey]DeliveryStatus{{1, 12}: DeliveryDelivered}, tracker.early)
+}
+
+func TestDelivery_cancelOnDone(t *testing.T) {
+	t.Parallel()
+	tracker := &deliveryTracker{}
+	delivery := newDelivery(tracker, 1, 10)
+	tracker.add(delivery)
+	ctx, cancel := context.WithCancel(context.Background())
+	delivery.cancelOnDone(ctx)
+	cancel()
+	<-delivery.Done()
+	assert.Equal(t, context.Canceled, delivery.Err())
+	assert.Empty(t, tracker.pending)
+
+	// resolving the delivery stops waiting for ctx
+	delivery = newDelivery(tracker, 1, 11)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	delivery.cancelOnDone(ctx)
+	delivery.resolve(DeliveryRead, nil)
+	assert.False(t, delivery.stop())
+}
+
+func TestBot_SendMsgAndWait(t *testing.T) {
+	t.Parallel()
+	acfactory.WithRunningBot(func(bot *Bot, botAcc AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *Rpc, accId AccountId) {
+			chatId := acfactory.CreateChat(bot.Rpc, botAcc, accRpc, accId)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			msgId, status, err := bot.SendMsgAndWait(ctx, botAcc, chatId, MsgData{Text: "hi"})
+			require.Nil(t, err)
+			assert.Equal(t, DeliveryDelivered, status)
+
+			delivery, err := bot.TrackDelivery(ctx, botAcc, msgId)
+			require.Nil(t, err)
+			<-delivery.Done()
+			assert.Equal(t, DeliveryDelivered, delivery.Status())
+			assert.Equal(t, "hi", acfactory.NextMsg(accRpc, accId).Text)
+		})
+	})
+}
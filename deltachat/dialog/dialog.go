@@ -0,0 +1,387 @@
+// Package to implement multi-step conversations (dialogs) in bots.
+//
+// A Flow is a sequence of steps, every step sends a prompt to the user and waits for a valid answer.
+// The state of the ongoing dialogs is persisted per (account, chat, contact) in a Store,
+// by default in the account's key-value store (see Bot.Store()).
+//
+// Typical usage:
+//
+//	dialogs := dialog.NewManager(nil)
+//	dialogs.Add(&dialog.Flow{
+//		Name: "signup",
+//		Steps: []dialog.Step{
+//			{Name: "name", Prompt: "What is your name?"},
+//			{Name: "email", Prompt: "What is your email?", Validate: validateEmail},
+//		},
+//		OnComplete: func(bot *deltachat.Bot, conv dialog.Conversation, answers map[string]string) {
+//			bot.Rpc.MiscSendTextMessage(conv.AccountId, conv.ChatId, "Welcome "+answers["name"])
+//		},
+//	})
+//	bot.OnNewMsg(dialogs.Middleware(func(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) {
+//		msg, _ := bot.Rpc.GetMessage(accId, msgId)
+//		if msg.Text == "/signup" {
+//			conv := dialog.Conversation{AccountId: accId, ChatId: msg.ChatId, ContactId: msg.FromId}
+//			dialogs.Start(bot, conv, "signup")
+//		}
+//	}))
+//	go dialogs.Run(ctx, bot) // abort dialogs whose step timeout expired
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+)
+
+// Default keyword to cancel an ongoing dialog.
+const DefaultCancelKeyword = "/cancel"
+
+// UnknownFlowErr is returned by Manager.Start() if there is no Flow with the given name.
+type UnknownFlowErr struct {
+	Name string
+}
+
+func (self *UnknownFlowErr) Error() string {
+	return fmt.Sprintf("unknown dialog flow: %q", self.Name)
+}
+
+// A conversation is identified by the account, the chat and the contact the bot is talking to.
+type Conversation struct {
+	AccountId deltachat.AccountId
+	ChatId    deltachat.ChatId
+	ContactId deltachat.ContactId
+}
+
+// A step of a Flow.
+type Step struct {
+	// Name of the step, used as key of the answer.
+	Name string
+	// Text sent to the user when the step starts.
+	Prompt string
+	// Optional answer validator, if it returns an error, the error text is sent to the user
+	// and the step is repeated.
+	Validate func(answer string) error
+	// Optional time to wait for an answer, after it the dialog is aborted.
+	// Expired dialogs are detected when the next message arrives or by Manager.Run()/Manager.Sweep().
+	Timeout time.Duration
+}
+
+// A multi-step dialog definition.
+type Flow struct {
+	Name  string
+	Steps []Step
+	// Called with the answers of all steps, keyed by step name, when the last step is answered.
+	OnComplete func(bot *deltachat.Bot, conv Conversation, answers map[string]string)
+	// Optional, called when the user cancels the dialog.
+	OnCancel func(bot *deltachat.Bot, conv Conversation)
+	// Optional, called when the step timeout expires.
+	OnTimeout func(bot *deltachat.Bot, conv Conversation)
+}
+
+// State of an ongoing dialog.
+type State struct {
+	Flow    string            `json:"flow"`
+	Step    int               `json:"step"`
+	Answers map[string]string `json:"answers"`
+	Updated int64             `json:"updated"` // Unix timestamp of the last step change
+}
+
+// Manager keeps track of the ongoing dialogs. It is safe for concurrent use,
+// the state changes of a conversation are serialized, flow callbacks are called without holding any lock.
+type Manager struct {
+	// Keyword to cancel the ongoing dialog, DefaultCancelKeyword if empty.
+	CancelKeyword string
+	// Text sent to the user when the dialog is cancelled, if empty nothing is sent.
+	CancelText string
+	// Text sent to the user when the dialog times out, if empty nothing is sent.
+	TimeoutText string
+	// How often Run() checks for expired dialogs, one minute if zero.
+	SweepInterval time.Duration
+
+	store Store
+	mutex sync.Mutex
+	flows map[string]*Flow
+	locks map[Conversation]*convLock
+	now   func() time.Time
+}
+
+// Lock of a conversation, removed when no goroutine holds or waits for it.
+type convLock struct {
+	mutex sync.Mutex
+	refs  int
+}
+
+// Create a new Manager saving the dialog states in the given Store.
+// If store is nil, a BotStore is used.
+func NewManager(store Store) *Manager {
+	if store == nil {
+		store = &BotStore{}
+	}
+	return &Manager{
+		CancelText:  "Cancelled.",
+		TimeoutText: "Timed out, please start again.",
+		store:       store,
+		flows:       make(map[string]*Flow),
+		now:         time.Now,
+	}
+}
+
+// Register a Flow. Adding a Flow with the same name as an existing one replaces it.
+func (self *Manager) Add(flow *Flow) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.flows[flow.Name] = flow
+}
+
+// Start the Flow with the given name in the given conversation, replacing any ongoing dialog,
+// and send the prompt of the first step.
+func (self *Manager) Start(bot *deltachat.Bot, conv Conversation, flowName string) error {
+	flow := self.flow(flowName)
+	if flow == nil {
+		return &UnknownFlowErr{Name: flowName}
+	}
+	if len(flow.Steps) == 0 {
+		if flow.OnComplete != nil {
+			flow.OnComplete(bot, conv, map[string]string{})
+		}
+		return nil
+	}
+	state := &State{Flow: flowName, Answers: map[string]string{}, Updated: self.now().Unix()}
+	unlock := self.lock(conv)
+	err := self.store.Save(bot, conv, state)
+	unlock()
+	if err != nil {
+		return err
+	}
+	return self.send(bot, conv, flow.Steps[0].Prompt)
+}
+
+// Cancel the ongoing dialog in the given conversation, if any.
+func (self *Manager) Cancel(bot *deltachat.Bot, conv Conversation) error {
+	defer self.lock(conv)()
+	return self.store.Delete(bot, conv)
+}
+
+// Get the state of the ongoing dialog in the given conversation, nil is returned if there is none.
+func (self *Manager) State(bot *deltachat.Bot, conv Conversation) (*State, error) {
+	return self.store.Load(bot, conv)
+}
+
+// Wrap the given NewMsgHandler so that messages belonging to an ongoing dialog are processed
+// by the Manager, other messages are passed to the given handler.
+// The returned handler is meant to be set with Bot.OnNewMsg().
+func (self *Manager) Middleware(next deltachat.NewMsgHandler) deltachat.NewMsgHandler {
+	return func(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) {
+		handled, _ := self.HandleMsg(bot, accId, msgId)
+		if !handled && next != nil {
+			next(bot, accId, msgId)
+		}
+	}
+}
+
+// Process the given message if it belongs to an ongoing dialog.
+// Returns true if the message was consumed by the dialog.
+func (self *Manager) HandleMsg(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) (bool, error) {
+	msg, err := bot.Rpc.GetMessage(accId, msgId)
+	if err != nil {
+		return false, err
+	}
+	if msg.FromId <= deltachat.ContactLastSpecial || msg.IsInfo {
+		return false, nil
+	}
+	conv := Conversation{AccountId: accId, ChatId: msg.ChatId, ContactId: msg.FromId}
+	unlock := self.lock(conv)
+	state, err := self.store.Load(bot, conv)
+	if err != nil || state == nil {
+		unlock()
+		return false, err
+	}
+	flow := self.flow(state.Flow)
+	if flow == nil || state.Step >= len(flow.Steps) {
+		err = self.store.Delete(bot, conv)
+		unlock()
+		return false, err
+	}
+	action, reply := self.process(flow, state, msg.Text)
+	if action == actionRetry || action == actionNext {
+		err = self.store.Save(bot, conv, state)
+	} else {
+		err = self.store.Delete(bot, conv)
+	}
+	unlock()
+
+	switch action {
+	case actionCancel:
+		if flow.OnCancel != nil {
+			flow.OnCancel(bot, conv)
+		}
+		self.send(bot, conv, self.CancelText) //nolint:errcheck
+	case actionTimeout:
+		self.timedOut(bot, conv, flow)
+	case actionRetry, actionNext:
+		if err == nil {
+			err = self.send(bot, conv, reply)
+		}
+	case actionComplete:
+		if flow.OnComplete != nil {
+			flow.OnComplete(bot, conv, state.Answers)
+		}
+	}
+	return true, err
+}
+
+type action int
+
+const (
+	actionCancel action = iota
+	actionTimeout
+	actionRetry
+	actionNext
+	actionComplete
+)
+
+// Apply the given answer to the state, returns the resulting action and the text to send.
+func (self *Manager) process(flow *Flow, state *State, answer string) (action, string) {
+	keyword := self.CancelKeyword
+	if keyword == "" {
+		keyword = DefaultCancelKeyword
+	}
+	if strings.EqualFold(strings.TrimSpace(answer), keyword) {
+		return actionCancel, ""
+	}
+
+	step := flow.Steps[state.Step]
+	now := self.now()
+	if expired(flow, state, now) {
+		return actionTimeout, ""
+	}
+	if step.Validate != nil {
+		if err := step.Validate(answer); err != nil {
+			return actionRetry, err.Error()
+		}
+	}
+
+	if state.Answers == nil {
+		state.Answers = map[string]string{}
+	}
+	state.Answers[step.Name] = answer
+	state.Step++
+	state.Updated = now.Unix()
+	if state.Step >= len(flow.Steps) {
+		return actionComplete, ""
+	}
+	return actionNext, flow.Steps[state.Step].Prompt
+}
+
+// Abort the dialogs of the given account whose step timeout expired, calling Flow.OnTimeout
+// and sending TimeoutText. Returns the first error found, the remaining dialogs are still processed.
+func (self *Manager) Sweep(bot *deltachat.Bot, accId deltachat.AccountId) error {
+	convs, err := self.store.Conversations(bot, accId)
+	if err != nil {
+		return err
+	}
+	now := self.now()
+	for _, conv := range convs {
+		flow, expireErr := self.expire(bot, conv, now)
+		err = errors.Join(err, expireErr)
+		if flow != nil {
+			self.timedOut(bot, conv, flow)
+		}
+	}
+	return err
+}
+
+// Remove the state of the dialog in the given conversation if its step timeout expired,
+// returns the Flow of the expired dialog or nil if it didn't expire.
+func (self *Manager) expire(bot *deltachat.Bot, conv Conversation, now time.Time) (*Flow, error) {
+	defer self.lock(conv)()
+	// the state is loaded again under the lock, it could have changed after listing the conversations
+	state, err := self.store.Load(bot, conv)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	flow := self.flow(state.Flow)
+	if flow == nil || state.Step >= len(flow.Steps) || !expired(flow, state, now) {
+		return nil, nil
+	}
+	return flow, self.store.Delete(bot, conv)
+}
+
+// Call Sweep() for all the bot accounts every SweepInterval until ctx is done.
+func (self *Manager) Run(ctx context.Context, bot *deltachat.Bot) {
+	interval := self.SweepInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		accIds, _ := bot.Rpc.GetAllAccountIds()
+		for _, accId := range accIds {
+			self.Sweep(bot, accId) //nolint:errcheck
+		}
+	}
+}
+
+// Returns true if the timeout of the current step expired.
+func expired(flow *Flow, state *State, now time.Time) bool {
+	step := flow.Steps[state.Step]
+	return step.Timeout > 0 && now.Sub(time.Unix(state.Updated, 0)) > step.Timeout
+}
+
+func (self *Manager) timedOut(bot *deltachat.Bot, conv Conversation, flow *Flow) {
+	if flow.OnTimeout != nil {
+		flow.OnTimeout(bot, conv)
+	}
+	self.send(bot, conv, self.TimeoutText) //nolint:errcheck
+}
+
+func (self *Manager) flow(name string) *Flow {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.flows[name]
+}
+
+// Lock the given conversation, returns the function to unlock it.
+func (self *Manager) lock(conv Conversation) func() {
+	self.mutex.Lock()
+	if self.locks == nil {
+		self.locks = make(map[Conversation]*convLock)
+	}
+	lock, ok := self.locks[conv]
+	if !ok {
+		lock = &convLock{}
+		self.locks[conv] = lock
+	}
+	lock.refs++
+	self.mutex.Unlock()
+
+	lock.mutex.Lock()
+	return func() {
+		lock.mutex.Unlock()
+		self.mutex.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(self.locks, conv)
+		}
+		self.mutex.Unlock()
+	}
+}
+
+func (self *Manager) send(bot *deltachat.Bot, conv Conversation, text string) error {
+	if text == "" {
+		return nil
+	}
+	_, err := bot.Rpc.MiscSendTextMessage(conv.AccountId, conv.ChatId, text)
+	return err
+}
@@ -0,0 +1,202 @@
+package dialog
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFlow = &Flow{
+	Name: "signup",
+	Steps: []Step{
+		{Name: "name", Prompt: "name?"},
+		{Name: "email", Prompt: "email?", Timeout: time.Minute, Validate: func(answer string) error {
+			if !strings.Contains(answer, "@") {
+				return errors.New("invalid email")
+			}
+			return nil
+		}},
+	},
+}
+
+func TestManager_process(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000, 0)
+	manager := NewManager(&MemoryStore{})
+	manager.now = func() time.Time { return now }
+	state := &State{Flow: "signup", Updated: now.Unix()}
+
+	action, reply := manager.process(testFlow, state, "Alice")
+	assert.Equal(t, actionNext, action)
+	assert.Equal(t, "email?", reply)
+	assert.Equal(t, 1, state.Step)
+
+	action, reply = manager.process(testFlow, state, "alice")
+	assert.Equal(t, actionRetry, action)
+	assert.Equal(t, "invalid email", reply)
+	assert.Equal(t, 1, state.Step)
+
+	action, _ = manager.process(testFlow, state, " /CANCEL ")
+	assert.Equal(t, actionCancel, action)
+
+	action, _ = manager.process(testFlow, state, "alice@example.org")
+	assert.Equal(t, actionComplete, action)
+	assert.Equal(t, map[string]string{"name": "Alice", "email": "alice@example.org"}, state.Answers)
+
+	state = &State{Flow: "signup", Step: 1, Updated: now.Unix()}
+	now = now.Add(2 * time.Minute)
+	action, _ = manager.process(testFlow, state, "alice@example.org")
+	assert.Equal(t, actionTimeout, action)
+}
+
+func TestManager_Sweep(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000, 0)
+	store := &MemoryStore{}
+	manager := NewManager(store)
+	manager.now = func() time.Time { return now }
+	manager.TimeoutText = ""
+	var expired []Conversation
+	flow := *testFlow
+	flow.OnTimeout = func(bot *deltachat.Bot, conv Conversation) { expired = append(expired, conv) }
+	manager.Add(&flow)
+
+	conv1 := Conversation{AccountId: 1, ChatId: 10, ContactId: 11}
+	conv2 := Conversation{AccountId: 1, ChatId: 12, ContactId: 13}
+	conv3 := Conversation{AccountId: 2, ChatId: 10, ContactId: 11}
+	require.Nil(t, store.Save(nil, conv1, &State{Flow: "signup", Step: 1, Updated: now.Unix()}))
+	require.Nil(t, store.Save(nil, conv2, &State{Flow: "signup", Step: 0, Updated: now.Unix()}))
+	require.Nil(t, store.Save(nil, conv3, &State{Flow: "signup", Step: 1, Updated: now.Unix()}))
+
+	require.Nil(t, manager.Sweep(nil, 1))
+	assert.Empty(t, expired)
+
+	now = now.Add(2 * time.Minute)
+	require.Nil(t, manager.Sweep(nil, 1))
+	assert.Equal(t, []Conversation{conv1}, expired)
+	state, _ := store.Load(nil, conv1)
+	assert.Nil(t, state)
+	state, _ = store.Load(nil, conv2) // the first step has no timeout
+	assert.NotNil(t, state)
+	state, _ = store.Load(nil, conv3) // other account
+	assert.NotNil(t, state)
+}
+
+func TestManager_lock(t *testing.T) {
+	t.Parallel()
+	store := &MemoryStore{}
+	manager := NewManager(store)
+	manager.TimeoutText = ""
+	conv := Conversation{AccountId: 1, ChatId: 10, ContactId: 11}
+
+	var wg sync.WaitGroup
+	counter := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			unlock := manager.lock(conv)
+			counter++
+			unlock()
+		}()
+		go func() {
+			defer wg.Done()
+			manager.Add(testFlow)
+			require.Nil(t, manager.Sweep(nil, 1))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 20, counter)
+	assert.Empty(t, manager.locks)
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+	store := &MemoryStore{}
+	conv := Conversation{AccountId: 1, ChatId: 2, ContactId: 3}
+	state, err := store.Load(nil, conv)
+	require.Nil(t, err)
+	assert.Nil(t, state)
+
+	require.Nil(t, store.Save(nil, conv, &State{Flow: "test", Answers: map[string]string{"a": "b"}}))
+	state, err = store.Load(nil, conv)
+	require.Nil(t, err)
+	assert.Equal(t, "test", state.Flow)
+	state.Answers["a"] = "changed"
+	state, _ = store.Load(nil, conv)
+	assert.Equal(t, "b", state.Answers["a"])
+
+	require.Nil(t, store.Delete(nil, conv))
+	state, err = store.Load(nil, conv)
+	require.Nil(t, err)
+	assert.Nil(t, state)
+}
+
+func TestBotStore(t *testing.T) {
+	t.Parallel()
+	acfactory.WithUnconfiguredBot(func(bot *deltachat.Bot, botAcc deltachat.AccountId) {
+		store := &BotStore{}
+		conv := Conversation{AccountId: botAcc, ChatId: 10, ContactId: 11}
+		state, err := store.Load(bot, conv)
+		require.Nil(t, err)
+		assert.Nil(t, state)
+
+		require.Nil(t, store.Save(bot, conv, &State{Flow: "test", Step: 1}))
+		state, err = store.Load(bot, conv)
+		require.Nil(t, err)
+		assert.Equal(t, 1, state.Step)
+
+		convs, err := store.Conversations(bot, botAcc)
+		require.Nil(t, err)
+		assert.Equal(t, []Conversation{conv}, convs)
+
+		require.Nil(t, store.Delete(bot, conv))
+		state, err = store.Load(bot, conv)
+		require.Nil(t, err)
+		assert.Nil(t, state)
+	})
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+	acfactory.WithRunningBot(func(bot *deltachat.Bot, botAcc deltachat.AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *deltachat.Rpc, accId deltachat.AccountId) {
+			results := make(chan map[string]string, 1)
+			manager := NewManager(nil)
+			flow := *testFlow
+			flow.OnComplete = func(bot *deltachat.Bot, conv Conversation, answers map[string]string) {
+				results <- answers
+			}
+			manager.Add(&flow)
+			bot.OnNewMsg(manager.Middleware(func(bot *deltachat.Bot, botAcc deltachat.AccountId, msgId deltachat.MsgId) {
+				msg, _ := bot.Rpc.GetMessage(botAcc, msgId)
+				if msg.Text == "/signup" {
+					conv := Conversation{AccountId: botAcc, ChatId: msg.ChatId, ContactId: msg.FromId}
+					assert.Nil(t, manager.Start(bot, conv, "signup"))
+				}
+			}))
+
+			chatWithBot := acfactory.CreateChat(accRpc, accId, bot.Rpc, botAcc)
+			send := func(text string) string {
+				_, err := accRpc.MiscSendTextMessage(accId, chatWithBot, text)
+				require.Nil(t, err)
+				return acfactory.NextMsg(accRpc, accId).Text
+			}
+			assert.Equal(t, "name?", send("/signup"))
+			assert.Equal(t, "email?", send("Alice"))
+			assert.Equal(t, "invalid email", send("alice"))
+			_, err := accRpc.MiscSendTextMessage(accId, chatWithBot, "alice@example.org")
+			require.Nil(t, err)
+			assert.Equal(t, map[string]string{"name": "Alice", "email": "alice@example.org"}, <-results)
+
+			assert.Equal(t, "name?", send("/signup"))
+			assert.Equal(t, "Cancelled.", send("/cancel"))
+		})
+	})
+}
@@ -0,0 +1,118 @@
+package dialog
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+)
+
+// Store persists the state of the ongoing dialogs.
+type Store interface {
+	// Get the state of the dialog in the given conversation, nil if there is no ongoing dialog.
+	Load(bot *deltachat.Bot, conv Conversation) (*State, error)
+	// Save the state of the dialog in the given conversation.
+	Save(bot *deltachat.Bot, conv Conversation, state *State) error
+	// Remove the state of the dialog in the given conversation.
+	Delete(bot *deltachat.Bot, conv Conversation) error
+	// Get the conversations of the given account with an ongoing dialog.
+	Conversations(bot *deltachat.Bot, accId deltachat.AccountId) ([]Conversation, error)
+}
+
+// BotStore saves the dialog states in the "dialog" namespace of the account's deltachat.Store,
+// see deltachat.Bot.Store().
+type BotStore struct{}
+
+func (self *BotStore) Load(bot *deltachat.Bot, conv Conversation) (*State, error) {
+	var state State
+	ok, err := self.store(bot, conv).Get(self.key(conv), &state)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (self *BotStore) Save(bot *deltachat.Bot, conv Conversation, state *State) error {
+	return self.store(bot, conv).Set(self.key(conv), state)
+}
+
+func (self *BotStore) Delete(bot *deltachat.Bot, conv Conversation) error {
+	return self.store(bot, conv).Delete(self.key(conv))
+}
+
+func (self *BotStore) Conversations(bot *deltachat.Bot, accId deltachat.AccountId) ([]Conversation, error) {
+	keys, err := bot.Store(accId).Namespace("dialog").Keys("")
+	if err != nil {
+		return nil, err
+	}
+	convs := make([]Conversation, 0, len(keys))
+	for _, key := range keys {
+		conv := Conversation{AccountId: accId}
+		if _, err = fmt.Sscanf(key, "%d.%d", &conv.ChatId, &conv.ContactId); err == nil {
+			convs = append(convs, conv)
+		}
+	}
+	return convs, nil
+}
+
+func (self *BotStore) store(bot *deltachat.Bot, conv Conversation) *deltachat.Store {
+	return bot.Store(conv.AccountId).Namespace("dialog")
+}
+
+func (self *BotStore) key(conv Conversation) string {
+	return fmt.Sprintf("%v.%v", conv.ChatId, conv.ContactId)
+}
+
+// MemoryStore keeps the dialog states in memory, they are lost when the program exits.
+type MemoryStore struct {
+	mutex  sync.Mutex
+	states map[Conversation]State
+}
+
+func (self *MemoryStore) Load(bot *deltachat.Bot, conv Conversation) (*State, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	state, ok := self.states[conv]
+	if !ok {
+		return nil, nil
+	}
+	return copyState(&state), nil
+}
+
+func (self *MemoryStore) Save(bot *deltachat.Bot, conv Conversation, state *State) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.states == nil {
+		self.states = make(map[Conversation]State)
+	}
+	self.states[conv] = *copyState(state)
+	return nil
+}
+
+func (self *MemoryStore) Delete(bot *deltachat.Bot, conv Conversation) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	delete(self.states, conv)
+	return nil
+}
+
+func (self *MemoryStore) Conversations(bot *deltachat.Bot, accId deltachat.AccountId) ([]Conversation, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	var convs []Conversation
+	for conv := range self.states {
+		if conv.AccountId == accId {
+			convs = append(convs, conv)
+		}
+	}
+	return convs, nil
+}
+
+func copyState(state *State) *State {
+	stateCopy := *state
+	stateCopy.Answers = make(map[string]string, len(state.Answers))
+	for key, value := range state.Answers {
+		stateCopy.Answers[key] = value
+	}
+	return &stateCopy
+}
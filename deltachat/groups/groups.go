@@ -0,0 +1,345 @@
+// Package to help bots administrate groups.
+//
+// It provides declarative membership synchronization (see Sync()), a persistent list of
+// group administrators with permission checks, welcome messages and typed member change events.
+//
+// Typical usage:
+//
+//	manager := groups.NewManager()
+//	manager.Welcome = func(bot *deltachat.Bot, change *groups.MemberChange) string {
+//		return "Welcome! Please read the group rules."
+//	}
+//	manager.OnMemberChange = func(bot *deltachat.Bot, change *groups.MemberChange) {
+//		log.Printf("%v: %v by %v", change.Type, change.Target, change.Actor)
+//	}
+//	bot.OnNewMsg(manager.Middleware(onNewMsg))
+package groups
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+)
+
+// Type of a MemberChange.
+type ChangeType int
+
+const (
+	MemberAdded ChangeType = iota
+	MemberRemoved
+)
+
+func (self ChangeType) String() string {
+	switch self {
+	case MemberAdded:
+		return "MemberAdded"
+	case MemberRemoved:
+		return "MemberRemoved"
+	default:
+		return fmt.Sprintf("ChangeType(%d)", int(self))
+	}
+}
+
+// A change in the members of a group.
+type MemberChange struct {
+	AccountId deltachat.AccountId
+	ChatId    deltachat.ChatId
+	MsgId     deltachat.MsgId // The system message announcing the change
+	Type      ChangeType
+	Actor     deltachat.ContactId // The contact that did the change
+	Target    deltachat.ContactId // The contact that was added or removed
+}
+
+// Handler called for every member change.
+type MemberChangeHandler func(bot *deltachat.Bot, change *MemberChange)
+
+// PermissionDeniedErr is returned if a contact that is not a group administrator
+// attempts an administrative action.
+type PermissionDeniedErr struct {
+	ChatId    deltachat.ChatId
+	ContactId deltachat.ContactId
+}
+
+func (self *PermissionDeniedErr) Error() string {
+	return fmt.Sprintf("contact %v is not an administrator of chat %v", self.ContactId, self.ChatId)
+}
+
+// UnknownMemberErr is returned by Manager.HandleMsg() if the added/removed member can not be determined,
+// this can happen with core versions not providing deltachat.MsgSnapshot.InfoContactId
+// if the message text is not in English.
+type UnknownMemberErr struct {
+	MsgId deltachat.MsgId
+}
+
+func (self *UnknownMemberErr) Error() string {
+	return fmt.Sprintf("unknown member in system message %v", self.MsgId)
+}
+
+// Difference between the current and the desired members of a group.
+type Diff struct {
+	Add    []deltachat.ContactId
+	Remove []deltachat.ContactId
+}
+
+// Return true if there is nothing to change.
+func (self *Diff) IsEmpty() bool {
+	return len(self.Add) == 0 && len(self.Remove) == 0
+}
+
+// Compute the changes needed to go from the current members to the desired ones.
+// ContactSelf is never removed.
+func ComputeDiff(current, desired []deltachat.ContactId) *Diff {
+	currentSet := toSet(current)
+	desiredSet := toSet(desired)
+	diff := &Diff{}
+	for id := range desiredSet {
+		if !currentSet[id] {
+			diff.Add = append(diff.Add, id)
+		}
+	}
+	for id := range currentSet {
+		if !desiredSet[id] && id != deltachat.ContactSelf {
+			diff.Remove = append(diff.Remove, id)
+		}
+	}
+	sortIds(diff.Add)
+	sortIds(diff.Remove)
+	return diff
+}
+
+// Make the given contacts the members of the group, adding and removing members as needed.
+// Returns the applied changes.
+func Sync(rpc *deltachat.Rpc, accId deltachat.AccountId, chatId deltachat.ChatId, desired []deltachat.ContactId) (*Diff, error) {
+	current, err := rpc.GetChatContacts(accId, chatId)
+	if err != nil {
+		return nil, err
+	}
+	diff := ComputeDiff(current, desired)
+	for _, id := range diff.Add {
+		if err = rpc.AddContactToChat(accId, chatId, id); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range diff.Remove {
+		if err = rpc.RemoveContactFromChat(accId, chatId, id); err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
+
+// Manager reports the member changes of the bot's groups and keeps their administrators.
+type Manager struct {
+	// Optional, called for every member change.
+	OnMemberChange MemberChangeHandler
+	// Optional, returns the text sent to the group when a member joins, if empty nothing is sent.
+	Welcome func(bot *deltachat.Bot, change *MemberChange) string
+	// Called if there is an error processing a message, can be nil.
+	OnError func(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId, err error)
+}
+
+// Create a new Manager.
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Wrap the given NewMsgHandler so that group system messages are processed by the Manager
+// before being passed to the given handler.
+// The returned handler is meant to be set with Bot.OnNewMsg().
+func (self *Manager) Middleware(next deltachat.NewMsgHandler) deltachat.NewMsgHandler {
+	return func(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) {
+		if err := self.HandleMsg(bot, accId, msgId); err != nil && self.OnError != nil {
+			self.OnError(bot, accId, msgId, err)
+		}
+		if next != nil {
+			next(bot, accId, msgId)
+		}
+	}
+}
+
+// Process the given message, emitting a member change event if it is a member added/removed
+// system message. The actor and target are taken from the message, see deltachat.Rpc.ParseSysmsg().
+// With core versions not providing deltachat.MsgSnapshot.InfoContactId, the target is parsed
+// from the message text like deltachat.ParseMemberAdded() and deltachat.ParseMemberRemoved() do.
+func (self *Manager) HandleMsg(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) error {
+	msg, err := bot.Rpc.GetMessage(accId, msgId)
+	if err != nil {
+		return err
+	}
+	if msg.SystemMessageType != deltachat.SysmsgMemberAddedToGroup && msg.SystemMessageType != deltachat.SysmsgMemberRemovedFromGroup {
+		return nil
+	}
+	info, err := bot.Rpc.ParseSysmsg(accId, msg)
+	if err != nil {
+		return err
+	}
+	if info.Target == 0 {
+		info.Target = parseTarget(bot.Rpc, accId, msg)
+	}
+	change, err := newMemberChange(accId, info)
+	if err != nil {
+		return err
+	}
+
+	if self.OnMemberChange != nil {
+		self.OnMemberChange(bot, change)
+	}
+	if change.Type == MemberAdded && change.Target != deltachat.ContactSelf && self.Welcome != nil {
+		if text := self.Welcome(bot, change); text != "" {
+			_, err = bot.Rpc.MiscSendTextMessage(accId, change.ChatId, text)
+		}
+	}
+	return err
+}
+
+// Parse the target of a member added/removed system message from its text, zero is returned if unknown.
+func parseTarget(rpc *deltachat.Rpc, accId deltachat.AccountId, msg *deltachat.MsgSnapshot) deltachat.ContactId {
+	parse := deltachat.ParseMemberAdded
+	if msg.SystemMessageType == deltachat.SysmsgMemberRemovedFromGroup {
+		parse = deltachat.ParseMemberRemoved
+	}
+	_, target, err := parse(*rpc, accId, msg)
+	if err != nil {
+		return 0
+	}
+	return target
+}
+
+// Get the MemberChange of a member added/removed system message.
+func newMemberChange(accId deltachat.AccountId, info *deltachat.SysmsgInfo) (*MemberChange, error) {
+	if info.Target == 0 {
+		return nil, &UnknownMemberErr{MsgId: info.MsgId}
+	}
+	change := &MemberChange{
+		AccountId: accId,
+		ChatId:    info.ChatId,
+		MsgId:     info.MsgId,
+		Type:      MemberAdded,
+		Actor:     info.Actor,
+		Target:    info.Target,
+	}
+	if info.Type == deltachat.SysmsgMemberRemovedFromGroup {
+		change.Type = MemberRemoved
+	}
+	return change, nil
+}
+
+// Add a contact to the group, actor must be a group administrator.
+func (self *Manager) AddMember(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId, actor, target deltachat.ContactId) error {
+	if err := self.CheckAdmin(bot, accId, chatId, actor); err != nil {
+		return err
+	}
+	return bot.Rpc.AddContactToChat(accId, chatId, target)
+}
+
+// Remove a contact from the group, actor must be a group administrator.
+func (self *Manager) RemoveMember(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId, actor, target deltachat.ContactId) error {
+	if err := self.CheckAdmin(bot, accId, chatId, actor); err != nil {
+		return err
+	}
+	return bot.Rpc.RemoveContactFromChat(accId, chatId, target)
+}
+
+// Rename the group, actor must be a group administrator.
+func (self *Manager) SetName(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId, actor deltachat.ContactId, name string) error {
+	if err := self.CheckAdmin(bot, accId, chatId, actor); err != nil {
+		return err
+	}
+	return bot.Rpc.SetChatName(accId, chatId, name)
+}
+
+// Get the administrators of the given group.
+func (self *Manager) Admins(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId) ([]deltachat.ContactId, error) {
+	var admins []deltachat.ContactId
+	_, err := adminStore(bot, accId).Get(adminKey(chatId), &admins)
+	return admins, err
+}
+
+// Make the given contacts administrators of the group. The list is persisted with Bot.Store().
+func (self *Manager) AddAdmin(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId, contactIds ...deltachat.ContactId) error {
+	return self.updateAdmins(bot, accId, chatId, func(admins map[deltachat.ContactId]bool) {
+		for _, id := range contactIds {
+			admins[id] = true
+		}
+	})
+}
+
+// Remove the given contacts from the administrators of the group.
+func (self *Manager) RemoveAdmin(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId, contactIds ...deltachat.ContactId) error {
+	return self.updateAdmins(bot, accId, chatId, func(admins map[deltachat.ContactId]bool) {
+		for _, id := range contactIds {
+			delete(admins, id)
+		}
+	})
+}
+
+// Return true if the given contact is an administrator of the group.
+// The bot itself (ContactSelf) is always an administrator.
+func (self *Manager) IsAdmin(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId, contactId deltachat.ContactId) (bool, error) {
+	if contactId == deltachat.ContactSelf {
+		return true, nil
+	}
+	admins, err := self.Admins(bot, accId, chatId)
+	if err != nil {
+		return false, err
+	}
+	return toSet(admins)[contactId], nil
+}
+
+// Return PermissionDeniedErr if the given contact is not an administrator of the group.
+func (self *Manager) CheckAdmin(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId, contactId deltachat.ContactId) error {
+	isAdmin, err := self.IsAdmin(bot, accId, chatId, contactId)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return &PermissionDeniedErr{ChatId: chatId, ContactId: contactId}
+	}
+	return nil
+}
+
+func (self *Manager) updateAdmins(bot *deltachat.Bot, accId deltachat.AccountId, chatId deltachat.ChatId, update func(map[deltachat.ContactId]bool)) error {
+	store := adminStore(bot, accId)
+	for {
+		var old []deltachat.ContactId
+		found, err := store.Get(adminKey(chatId), &old)
+		if err != nil {
+			return err
+		}
+		admins := toSet(old)
+		update(admins)
+		ids := make([]deltachat.ContactId, 0, len(admins))
+		for id := range admins {
+			ids = append(ids, id)
+		}
+		sortIds(ids)
+		var oldValue any
+		if found {
+			oldValue = old
+		}
+		if ok, err := store.CompareAndSet(adminKey(chatId), oldValue, ids); err != nil || ok {
+			return err
+		}
+	}
+}
+
+func adminStore(bot *deltachat.Bot, accId deltachat.AccountId) *deltachat.Store {
+	return bot.Store(accId).Namespace("groups")
+}
+
+func adminKey(chatId deltachat.ChatId) string {
+	return fmt.Sprintf("admins.%v", chatId)
+}
+
+func toSet(ids []deltachat.ContactId) map[deltachat.ContactId]bool {
+	set := make(map[deltachat.ContactId]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func sortIds(ids []deltachat.ContactId) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
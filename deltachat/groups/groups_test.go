@@ -0,0 +1,160 @@
+package groups
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeDiff(t *testing.T) {
+	t.Parallel()
+	diff := ComputeDiff([]deltachat.ContactId{deltachat.ContactSelf, 10, 11}, []deltachat.ContactId{12, 10, 13})
+	assert.Equal(t, []deltachat.ContactId{12, 13}, diff.Add)
+	assert.Equal(t, []deltachat.ContactId{11}, diff.Remove)
+	assert.False(t, diff.IsEmpty())
+
+	diff = ComputeDiff([]deltachat.ContactId{deltachat.ContactSelf, 10}, []deltachat.ContactId{10})
+	assert.True(t, diff.IsEmpty())
+}
+
+func TestChangeType_String(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "MemberAdded", MemberAdded.String())
+	assert.Equal(t, "MemberRemoved", MemberRemoved.String())
+	assert.Equal(t, "ChangeType(5)", ChangeType(5).String())
+}
+
+func TestNewMemberChange(t *testing.T) {
+	t.Parallel()
+	info := &deltachat.SysmsgInfo{Type: deltachat.SysmsgMemberRemovedFromGroup, MsgId: 20, ChatId: 10, Actor: 11, Target: 12}
+	change, err := newMemberChange(1, info)
+	require.Nil(t, err)
+	assert.Equal(t, &MemberChange{AccountId: 1, ChatId: 10, MsgId: 20, Type: MemberRemoved, Actor: 11, Target: 12}, change)
+
+	info.Type = deltachat.SysmsgMemberAddedToGroup
+	change, err = newMemberChange(1, info)
+	require.Nil(t, err)
+	assert.Equal(t, MemberAdded, change.Type)
+
+	info.Target = 0
+	_, err = newMemberChange(1, info)
+	var unknownErr *UnknownMemberErr
+	assert.ErrorAs(t, err, &unknownErr)
+}
+
+func TestParseTarget(t *testing.T) {
+	t.Parallel()
+	rpc := &deltachat.Rpc{}
+	msg := &deltachat.MsgSnapshot{FromId: 11, SystemMessageType: deltachat.SysmsgMemberRemovedFromGroup, Text: "Group left by alice@example.org."}
+	assert.Equal(t, deltachat.ContactId(11), parseTarget(rpc, 1, msg))
+
+	msg.InfoContactId = 12
+	assert.Equal(t, deltachat.ContactId(12), parseTarget(rpc, 1, msg))
+
+	msg = &deltachat.MsgSnapshot{FromId: 11, SystemMessageType: deltachat.SysmsgMemberAddedToGroup, Text: "Mitglied hinzugefügt."}
+	assert.Zero(t, parseTarget(rpc, 1, msg))
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *deltachat.Rpc, accId deltachat.AccountId) {
+		chatId, err := rpc.CreateGroupChat(accId, "test group", false)
+		require.Nil(t, err)
+		contact1, err := rpc.CreateContact(accId, "null1@example.org", "")
+		require.Nil(t, err)
+		contact2, err := rpc.CreateContact(accId, "null2@example.org", "")
+		require.Nil(t, err)
+
+		diff, err := Sync(rpc, accId, chatId, []deltachat.ContactId{contact1, contact2})
+		require.Nil(t, err)
+		assert.Equal(t, []deltachat.ContactId{contact1, contact2}, diff.Add)
+		assert.Empty(t, diff.Remove)
+
+		diff, err = Sync(rpc, accId, chatId, []deltachat.ContactId{contact2})
+		require.Nil(t, err)
+		assert.Empty(t, diff.Add)
+		assert.Equal(t, []deltachat.ContactId{contact1}, diff.Remove)
+
+		members, err := rpc.GetChatContacts(accId, chatId)
+		require.Nil(t, err)
+		assert.ElementsMatch(t, []deltachat.ContactId{deltachat.ContactSelf, contact2}, members)
+	})
+}
+
+func TestManager_admins(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineBot(func(bot *deltachat.Bot, botAcc deltachat.AccountId) {
+		manager := NewManager()
+		chatId, err := bot.Rpc.CreateGroupChat(botAcc, "test group", false)
+		require.Nil(t, err)
+		admin, err := bot.Rpc.CreateContact(botAcc, "admin@example.org", "")
+		require.Nil(t, err)
+		user, err := bot.Rpc.CreateContact(botAcc, "user@example.org", "")
+		require.Nil(t, err)
+
+		isAdmin, err := manager.IsAdmin(bot, botAcc, chatId, deltachat.ContactSelf)
+		require.Nil(t, err)
+		assert.True(t, isAdmin)
+
+		require.Nil(t, manager.AddAdmin(bot, botAcc, chatId, admin))
+		admins, err := manager.Admins(bot, botAcc, chatId)
+		require.Nil(t, err)
+		assert.Equal(t, []deltachat.ContactId{admin}, admins)
+
+		require.Nil(t, manager.AddMember(bot, botAcc, chatId, admin, user))
+		err = manager.RemoveMember(bot, botAcc, chatId, user, admin)
+		var permErr *PermissionDeniedErr
+		require.True(t, errors.As(err, &permErr))
+		assert.Equal(t, user, permErr.ContactId)
+		require.Nil(t, manager.SetName(bot, botAcc, chatId, admin, "new name"))
+
+		require.Nil(t, manager.RemoveAdmin(bot, botAcc, chatId, admin))
+		require.NotNil(t, manager.CheckAdmin(bot, botAcc, chatId, admin))
+	})
+}
+
+func TestManager_HandleMsg(t *testing.T) {
+	t.Parallel()
+	acfactory.WithRunningBot(func(bot *deltachat.Bot, botAcc deltachat.AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *deltachat.Rpc, accId deltachat.AccountId) {
+			changes := make(chan *MemberChange, 1)
+			manager := NewManager()
+			manager.OnMemberChange = func(bot *deltachat.Bot, change *MemberChange) {
+				changes <- change
+			}
+			manager.Welcome = func(bot *deltachat.Bot, change *MemberChange) string {
+				return "welcome"
+			}
+			bot.OnNewMsg(manager.Middleware(nil))
+
+			botAddr, err := bot.Rpc.GetConfig(botAcc, "configured_addr")
+			require.Nil(t, err)
+			botContact, err := accRpc.CreateContact(accId, botAddr.Unwrap(), "")
+			require.Nil(t, err)
+			chatId, err := accRpc.CreateGroupChat(accId, "test group", false)
+			require.Nil(t, err)
+			require.Nil(t, accRpc.AddContactToChat(accId, chatId, botContact))
+			_, err = accRpc.MiscSendTextMessage(accId, chatId, "hi")
+			require.Nil(t, err)
+			acfactory.WaitForEventInChat(accRpc, accId, chatId, deltachat.EventMsgsChanged{})
+
+			contactId, err := accRpc.CreateContact(accId, "null@example.org", "")
+			require.Nil(t, err)
+			require.Nil(t, accRpc.AddContactToChat(accId, chatId, contactId))
+
+			change := <-changes
+			assert.Equal(t, MemberAdded, change.Type)
+			assert.NotEqual(t, deltachat.ContactSelf, change.Target)
+			assert.Greater(t, change.Actor, deltachat.ContactLastSpecial)
+			contact, err := bot.Rpc.GetContact(botAcc, change.Target)
+			require.Nil(t, err)
+			assert.Equal(t, "null@example.org", contact.Address)
+
+			msg := acfactory.NextMsg(accRpc, accId)
+			assert.Equal(t, "welcome", msg.Text)
+		})
+	})
+}
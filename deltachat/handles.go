@@ -0,0 +1,427 @@
+package deltachat
+
+import (
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+)
+
+// AccountHandle is a thin wrapper around an account ID, it allows to call
+// the Rpc methods of an account without passing the account ID around.
+//
+// Typical usage:
+//
+//	chat := rpc.Account(accId).Chat(chatId)
+//	msg, err := chat.SendText("hello")
+type AccountHandle struct {
+	Rpc *Rpc
+	Id  AccountId
+}
+
+// Get a handle for the account with the given ID.
+func (self *Rpc) Account(id AccountId) *AccountHandle {
+	return &AccountHandle{Rpc: self, Id: id}
+}
+
+// Get a handle for the chat with the given ID.
+func (self *AccountHandle) Chat(id ChatId) *ChatHandle {
+	return &ChatHandle{Account: self, Id: id}
+}
+
+// Get a handle for the contact with the given ID.
+func (self *AccountHandle) Contact(id ContactId) *ContactHandle {
+	return &ContactHandle{Account: self, Id: id}
+}
+
+// Get a handle for the message with the given ID.
+func (self *AccountHandle) Message(id MsgId) *MsgHandle {
+	return &MsgHandle{Account: self, Id: id}
+}
+
+// Get a handle for the self-contact.
+func (self *AccountHandle) SelfContact() *ContactHandle {
+	return self.Contact(ContactSelf)
+}
+
+// Remove the account.
+func (self *AccountHandle) Remove() error {
+	return self.Rpc.RemoveAccount(self.Id)
+}
+
+// Start the account I/O.
+func (self *AccountHandle) StartIo() error {
+	return self.Rpc.StartIo(self.Id)
+}
+
+// Stop the account I/O.
+func (self *AccountHandle) StopIo() error {
+	return self.Rpc.StopIo(self.Id)
+}
+
+// Check if the account is already configured.
+func (self *AccountHandle) IsConfigured() (bool, error) {
+	return self.Rpc.IsConfigured(self.Id)
+}
+
+// Configure the account with the currently set parameters.
+func (self *AccountHandle) Configure() error {
+	return self.Rpc.Configure(self.Id)
+}
+
+// Get system info for the account.
+func (self *AccountHandle) Info() (map[string]string, error) {
+	return self.Rpc.GetInfo(self.Id)
+}
+
+// Get an account configuration value.
+func (self *AccountHandle) GetConfig(key string) (option.Option[string], error) {
+	return self.Rpc.GetConfig(self.Id, key)
+}
+
+// Set an account configuration value.
+func (self *AccountHandle) SetConfig(key string, value option.Option[string]) error {
+	return self.Rpc.SetConfig(self.Id, key, value)
+}
+
+// Create a contact or get the existing one with the given address.
+func (self *AccountHandle) CreateContact(addr string, name string) (*ContactHandle, error) {
+	id, err := self.Rpc.CreateContact(self.Id, addr, name)
+	if err != nil {
+		return nil, err
+	}
+	return self.Contact(id), nil
+}
+
+// Get the known and unblocked contact with the given address.
+// If there is no such contact, nil is returned.
+func (self *AccountHandle) LookupContact(addr string) (*ContactHandle, error) {
+	id, err := self.Rpc.LookupContactIdByAddr(self.Id, addr)
+	if err != nil || id.IsNone() {
+		return nil, err
+	}
+	return self.Contact(id.Unwrap()), nil
+}
+
+// Get the contacts matching the given query.
+func (self *AccountHandle) Contacts(query *ContactQuery) ([]*ContactHandle, error) {
+	ids, err := query.Ids(self.Rpc, self.Id)
+	if err != nil {
+		return nil, err
+	}
+	return self.contacts(ids), nil
+}
+
+// Get the blocked contacts.
+func (self *AccountHandle) BlockedContacts() ([]*ContactHandle, error) {
+	snapshots, err := self.Rpc.GetBlockedContacts(self.Id)
+	if err != nil {
+		return nil, err
+	}
+	contacts := make([]*ContactHandle, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		contacts = append(contacts, self.Contact(snapshot.Id))
+	}
+	return contacts, nil
+}
+
+// Get the chats in the chat list, optionally filtered by the given query.
+func (self *AccountHandle) Chats(query option.Option[string]) ([]*ChatHandle, error) {
+	ids, err := self.Rpc.GetChatlistEntries(self.Id, option.None[uint](), query, option.None[ContactId]())
+	if err != nil {
+		return nil, err
+	}
+	chats := make([]*ChatHandle, 0, len(ids))
+	for _, id := range ids {
+		chats = append(chats, self.Chat(id))
+	}
+	return chats, nil
+}
+
+// Create a new group chat.
+func (self *AccountHandle) CreateGroup(name string, protected bool) (*ChatHandle, error) {
+	id, err := self.Rpc.CreateGroupChat(self.Id, name, protected)
+	if err != nil {
+		return nil, err
+	}
+	return self.Chat(id), nil
+}
+
+// Create a new broadcast list.
+func (self *AccountHandle) CreateBroadcastList() (*ChatHandle, error) {
+	id, err := self.Rpc.CreateBroadcastList(self.Id)
+	if err != nil {
+		return nil, err
+	}
+	return self.Chat(id), nil
+}
+
+// Get the fresh messages of all chats, see Rpc.GetFreshMsgs().
+func (self *AccountHandle) FreshMessages() ([]*MsgHandle, error) {
+	ids, err := self.Rpc.GetFreshMsgs(self.Id)
+	if err != nil {
+		return nil, err
+	}
+	return self.messages(ids), nil
+}
+
+func (self *AccountHandle) contacts(ids []ContactId) []*ContactHandle {
+	contacts := make([]*ContactHandle, 0, len(ids))
+	for _, id := range ids {
+		contacts = append(contacts, self.Contact(id))
+	}
+	return contacts
+}
+
+func (self *AccountHandle) messages(ids []MsgId) []*MsgHandle {
+	msgs := make([]*MsgHandle, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, self.Message(id))
+	}
+	return msgs
+}
+
+// ChatHandle is a thin wrapper around a chat ID and its account.
+type ChatHandle struct {
+	Account *AccountHandle
+	Id      ChatId
+}
+
+// Get the full snapshot of the chat.
+func (self *ChatHandle) Snapshot() (*FullChatSnapshot, error) {
+	return self.Account.Rpc.GetFullChatById(self.Account.Id, self.Id)
+}
+
+// Get the basic snapshot of the chat, cheaper than Snapshot().
+func (self *ChatHandle) BasicSnapshot() (*BasicChatSnapshot, error) {
+	return self.Account.Rpc.GetBasicChatInfo(self.Account.Id, self.Id)
+}
+
+// Send a message to the chat.
+func (self *ChatHandle) Send(data MsgData) (*MsgHandle, error) {
+	id, err := self.Account.Rpc.SendMsg(self.Account.Id, self.Id, data)
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.Message(id), nil
+}
+
+// Send a text message to the chat.
+func (self *ChatHandle) SendText(text string) (*MsgHandle, error) {
+	id, err := self.Account.Rpc.MiscSendTextMessage(self.Account.Id, self.Id, text)
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.Message(id), nil
+}
+
+// Get the members of the chat.
+func (self *ChatHandle) Members() ([]*ContactHandle, error) {
+	ids, err := self.Account.Rpc.GetChatContacts(self.Account.Id, self.Id)
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.contacts(ids), nil
+}
+
+// Add a member to the group.
+func (self *ChatHandle) AddMember(contact *ContactHandle) error {
+	return self.Account.Rpc.AddContactToChat(self.Account.Id, self.Id, contact.Id)
+}
+
+// Remove a member from the group.
+func (self *ChatHandle) RemoveMember(contact *ContactHandle) error {
+	return self.Account.Rpc.RemoveContactFromChat(self.Account.Id, self.Id, contact.Id)
+}
+
+// Get the messages in the chat.
+func (self *ChatHandle) Messages() ([]*MsgHandle, error) {
+	ids, err := self.Account.Rpc.GetMessageIds(self.Account.Id, self.Id, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.messages(ids), nil
+}
+
+// Set the group name.
+func (self *ChatHandle) SetName(name string) error {
+	return self.Account.Rpc.SetChatName(self.Account.Id, self.Id, name)
+}
+
+// Set the group profile image, pass option.None to remove it.
+func (self *ChatHandle) SetImage(path option.Option[string]) error {
+	return self.Account.Rpc.SetChatProfileImage(self.Account.Id, self.Id, path)
+}
+
+// Set the chat visibility.
+func (self *ChatHandle) SetVisibility(visibility ChatVisibility) error {
+	return self.Account.Rpc.SetChatVisibility(self.Account.Id, self.Id, visibility)
+}
+
+// Set the ephemeral messages timer in seconds, zero disables it.
+func (self *ChatHandle) SetEphemeralTimer(timer uint) error {
+	return self.Account.Rpc.SetChatEphemeralTimer(self.Account.Id, self.Id, timer)
+}
+
+// Accept the contact request.
+func (self *ChatHandle) Accept() error {
+	return self.Account.Rpc.AcceptChat(self.Account.Id, self.Id)
+}
+
+// Block the chat.
+func (self *ChatHandle) Block() error {
+	return self.Account.Rpc.BlockChat(self.Account.Id, self.Id)
+}
+
+// Delete the chat, see Rpc.DeleteChat().
+func (self *ChatHandle) Delete() error {
+	return self.Account.Rpc.DeleteChat(self.Account.Id, self.Id)
+}
+
+// Leave the group.
+func (self *ChatHandle) Leave() error {
+	return self.Account.Rpc.LeaveGroup(self.Account.Id, self.Id)
+}
+
+// Mark all messages in the chat as noticed.
+func (self *ChatHandle) MarkNoticed() error {
+	return self.Account.Rpc.MarknoticedChat(self.Account.Id, self.Id)
+}
+
+// Check if messages can be sent to the chat.
+func (self *ChatHandle) CanSend() (bool, error) {
+	return self.Account.Rpc.CanSend(self.Account.Id, self.Id)
+}
+
+// ContactHandle is a thin wrapper around a contact ID and its account.
+type ContactHandle struct {
+	Account *AccountHandle
+	Id      ContactId
+}
+
+// Get a snapshot of the contact.
+func (self *ContactHandle) Snapshot() (*ContactSnapshot, error) {
+	return self.Account.Rpc.GetContact(self.Account.Id, self.Id)
+}
+
+// Block the contact.
+func (self *ContactHandle) Block() error {
+	return self.Account.Rpc.BlockContact(self.Account.Id, self.Id)
+}
+
+// Unblock the contact.
+func (self *ContactHandle) Unblock() error {
+	return self.Account.Rpc.UnblockContact(self.Account.Id, self.Id)
+}
+
+// Delete the contact.
+func (self *ContactHandle) Delete() error {
+	return self.Account.Rpc.DeleteContact(self.Account.Id, self.Id)
+}
+
+// Set the name of the contact.
+func (self *ContactHandle) SetName(name string) error {
+	return self.Account.Rpc.ChangeContactName(self.Account.Id, self.Id, name)
+}
+
+// Get the encryption info of the contact.
+func (self *ContactHandle) EncryptionInfo() (string, error) {
+	return self.Account.Rpc.GetContactEncryptionInfo(self.Account.Id, self.Id)
+}
+
+// Create the 1:1 chat with the contact or get the existing one.
+func (self *ContactHandle) CreateChat() (*ChatHandle, error) {
+	id, err := self.Account.Rpc.CreateChatByContactId(self.Account.Id, self.Id)
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.Chat(id), nil
+}
+
+// MsgHandle is a thin wrapper around a message ID and its account.
+type MsgHandle struct {
+	Account *AccountHandle
+	Id      MsgId
+}
+
+// Get a snapshot of the message.
+func (self *MsgHandle) Snapshot() (*MsgSnapshot, error) {
+	return self.Account.Rpc.GetMessage(self.Account.Id, self.Id)
+}
+
+// Get the chat the message belongs to.
+func (self *MsgHandle) Chat() (*ChatHandle, error) {
+	snapshot, err := self.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.Chat(snapshot.ChatId), nil
+}
+
+// Send a reply quoting this message in the same chat.
+func (self *MsgHandle) Reply(data MsgData) (*MsgHandle, error) {
+	snapshot, err := self.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	id, err := self.Account.Rpc.Reply(self.Account.Id, snapshot, data)
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.Message(id), nil
+}
+
+// Send a reply quoting this message in the 1:1 chat with its sender.
+func (self *MsgHandle) ReplyPrivately(data MsgData) (*MsgHandle, error) {
+	snapshot, err := self.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	id, err := self.Account.Rpc.ReplyPrivately(self.Account.Id, snapshot, data)
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.Message(id), nil
+}
+
+// Send a reaction to this message, replacing any previous reaction.
+// Calling React() without reactions removes the previous reaction.
+func (self *MsgHandle) React(reaction ...string) (*MsgHandle, error) {
+	id, err := self.Account.Rpc.SendReaction(self.Account.Id, self.Id, reaction...)
+	if err != nil {
+		return nil, err
+	}
+	return self.Account.Message(id), nil
+}
+
+// Reconstruct the conversation tree this message belongs to, see Rpc.GetThread().
+func (self *MsgHandle) Thread() (*ThreadNode, error) {
+	return self.Account.Rpc.GetThread(self.Account.Id, self.Id)
+}
+
+// Get the HTML part of the message, if any.
+func (self *MsgHandle) Html() (option.Option[string], error) {
+	return self.Account.Rpc.GetMessageHtml(self.Account.Id, self.Id)
+}
+
+// Get the informational text of the message.
+func (self *MsgHandle) Info() (string, error) {
+	return self.Account.Rpc.GetMessageInfo(self.Account.Id, self.Id)
+}
+
+// Get the reactions to the message.
+func (self *MsgHandle) Reactions() (option.Option[Reactions], error) {
+	return self.Account.Rpc.GetMessageReactions(self.Account.Id, self.Id)
+}
+
+// Forward the message to the given chat.
+func (self *MsgHandle) Forward(chat *ChatHandle) error {
+	return self.Account.Rpc.ForwardMessages(self.Account.Id, []MsgId{self.Id}, chat.Id)
+}
+
+// Mark the message as seen.
+func (self *MsgHandle) MarkSeen() error {
+	return self.Account.Rpc.MarkseenMsgs(self.Account.Id, []MsgId{self.Id})
+}
+
+// Delete the message.
+func (self *MsgHandle) Delete() error {
+	return self.Account.Rpc.DeleteMessages(self.Account.Id, []MsgId{self.Id})
+}
@@ -0,0 +1,120 @@
+package deltachat
+
+import (
+	"testing"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandles(t *testing.T) {
+	t.Parallel()
+	rpc := &Rpc{}
+	acc := rpc.Account(1)
+	assert.Equal(t, rpc, acc.Rpc)
+	assert.Equal(t, ChatId(2), acc.Chat(2).Id)
+	assert.Equal(t, acc, acc.Contact(3).Account)
+	assert.Equal(t, MsgId(4), acc.Message(4).Id)
+	assert.Equal(t, ContactSelf, acc.SelfContact().Id)
+}
+
+func TestAccountHandle(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		acc := rpc.Account(accId)
+		configured, err := acc.IsConfigured()
+		require.Nil(t, err)
+		assert.True(t, configured)
+
+		require.Nil(t, acc.SetConfig("displayname", option.Some("test name")))
+		name, err := acc.GetConfig("displayname")
+		require.Nil(t, err)
+		assert.Equal(t, "test name", name.Unwrap())
+
+		contact, err := acc.CreateContact("null@localhost", "test")
+		require.Nil(t, err)
+		contact2, err := acc.LookupContact("null@localhost")
+		require.Nil(t, err)
+		assert.Equal(t, contact.Id, contact2.Id)
+		contact2, err = acc.LookupContact("unknown@localhost")
+		require.Nil(t, err)
+		assert.Nil(t, contact2)
+
+		contacts, err := acc.Contacts(NewContactQuery())
+		require.Nil(t, err)
+		assert.Len(t, contacts, 1)
+
+		require.Nil(t, contact.Block())
+		blocked, err := acc.BlockedContacts()
+		require.Nil(t, err)
+		assert.Len(t, blocked, 1)
+		require.Nil(t, contact.Unblock())
+
+		_, err = acc.CreateGroup("test group", false)
+		require.Nil(t, err)
+		chats, err := acc.Chats(option.Some("test group"))
+		require.Nil(t, err)
+		assert.Len(t, chats, 1)
+	})
+}
+
+func TestChatHandle(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		acc := rpc.Account(accId)
+		chat, err := acc.CreateGroup("test group", false)
+		require.Nil(t, err)
+		contact, err := acc.CreateContact("null@localhost", "test")
+		require.Nil(t, err)
+
+		require.Nil(t, chat.AddMember(contact))
+		members, err := chat.Members()
+		require.Nil(t, err)
+		assert.Len(t, members, 2)
+		require.Nil(t, chat.RemoveMember(contact))
+
+		require.Nil(t, chat.SetName("new name"))
+		snapshot, err := chat.BasicSnapshot()
+		require.Nil(t, err)
+		assert.Equal(t, "new name", snapshot.Name)
+
+		msg, err := chat.SendText("hello")
+		require.Nil(t, err)
+		reply, err := msg.Reply(MsgData{Text: "reply"})
+		require.Nil(t, err)
+		replySnapshot, err := reply.Snapshot()
+		require.Nil(t, err)
+		assert.Equal(t, msg.Id, replySnapshot.Quote.MessageId)
+
+		msgChat, err := reply.Chat()
+		require.Nil(t, err)
+		assert.Equal(t, chat.Id, msgChat.Id)
+
+		msgs, err := chat.Messages()
+		require.Nil(t, err)
+		assert.NotEmpty(t, msgs)
+
+		require.Nil(t, msg.Delete())
+		require.Nil(t, chat.Delete())
+	})
+}
+
+func TestContactHandle(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		contact, err := rpc.Account(accId).CreateContact("null@localhost", "test")
+		require.Nil(t, err)
+		require.Nil(t, contact.SetName("new name"))
+		snapshot, err := contact.Snapshot()
+		require.Nil(t, err)
+		assert.Equal(t, "new name", snapshot.Name)
+
+		chat, err := contact.CreateChat()
+		require.Nil(t, err)
+		assert.NotZero(t, chat.Id)
+
+		_, err = contact.EncryptionInfo()
+		require.Nil(t, err)
+	})
+}
@@ -0,0 +1,93 @@
+package deltachat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Health of an account, see BotHealth.
+type AccountHealth struct {
+	Id           AccountId `json:"id"`
+	Configured   bool      `json:"configured"`
+	Connectivity uint      `json:"connectivity"` // Raw value of Rpc.GetConnectivity(), see NewConnectivity()
+	Error        string    `json:"error,omitempty"`
+}
+
+// Health report of a Bot, see Bot.Health().
+type BotHealth struct {
+	TransportAlive bool            `json:"transportAlive"`
+	Running        bool            `json:"running"`
+	Accounts       []AccountHealth `json:"accounts"`
+	LastEventAge   float64         `json:"lastEventAge"` // Seconds since the last event, -1 if no event was received
+	QueueDepth     int             `json:"queueDepth"`   // Messages waiting in the SendQueue, if any
+	Live           bool            `json:"live"`         // The transport is alive and the bot is running
+	Ready          bool            `json:"ready"`        // Live and all accounts are configured and connected
+}
+
+// Get the current health of the bot. The given context limits the time spent querying the core.
+func (self *Bot) Health(ctx context.Context) *BotHealth {
+	health := &BotHealth{Running: self.IsRunning(), Accounts: []AccountHealth{}, LastEventAge: -1}
+	if lastEvent := self.lastEvent.Load(); lastEvent != 0 {
+		health.LastEventAge = time.Since(time.Unix(0, lastEvent)).Seconds()
+	}
+	if self.sendQueue != nil {
+		health.QueueDepth = self.sendQueue.Metrics().Depth
+	}
+
+	rpc := &Rpc{Context: ctx, Transport: self.Rpc.Transport}
+	accIds, err := rpc.GetAllAccountIds()
+	health.TransportAlive = err == nil
+	health.Live = health.TransportAlive && health.Running
+	health.Ready = health.Live
+	for _, accId := range accIds {
+		account := AccountHealth{Id: accId}
+		account.Configured, err = rpc.IsConfigured(accId)
+		if err == nil {
+			account.Connectivity, err = rpc.GetConnectivity(accId)
+		}
+		if err != nil {
+			account.Error = err.Error()
+		}
+		if !account.Configured || NewConnectivity(account.Connectivity) < ConnectivityWorking {
+			health.Ready = false
+		}
+		health.Accounts = append(health.Accounts, account)
+	}
+	return health
+}
+
+// Get an http.Handler for liveness probes serving Bot.Health() as JSON.
+// The status code is 200 if the bot is live or 503 otherwise.
+func (self *Bot) LivenessHandler() http.Handler {
+	return &healthHandler{bot: self, readiness: false}
+}
+
+// Get an http.Handler for readiness probes serving Bot.Health() as JSON.
+// The status code is 200 if the bot is ready or 503 otherwise.
+func (self *Bot) ReadinessHandler() http.Handler {
+	return &healthHandler{bot: self, readiness: true}
+}
+
+type healthHandler struct {
+	bot       *Bot
+	readiness bool
+}
+
+func (self *healthHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	defer cancel()
+	health := self.bot.Health(ctx)
+	ok := health.Live
+	if self.readiness {
+		ok = health.Ready
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	if ok {
+		writer.WriteHeader(http.StatusOK)
+	} else {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(writer).Encode(health) //nolint:errcheck
+}
@@ -0,0 +1,50 @@
+package deltachat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBot_Health(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineBot(func(bot *Bot, botAcc AccountId) {
+		health := bot.Health(context.Background())
+		assert.True(t, health.TransportAlive)
+		assert.False(t, health.Running)
+		assert.False(t, health.Live)
+		assert.False(t, health.Ready)
+		require.Len(t, health.Accounts, 1)
+		assert.Equal(t, botAcc, health.Accounts[0].Id)
+		assert.True(t, health.Accounts[0].Configured)
+
+		recorder := httptest.NewRecorder()
+		bot.LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		var body BotHealth
+		require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		assert.True(t, body.TransportAlive)
+	})
+}
+
+func TestBot_LivenessHandler(t *testing.T) {
+	t.Parallel()
+	acfactory.WithRunningBot(func(bot *Bot, botAcc AccountId) {
+		recorder := httptest.NewRecorder()
+		bot.LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		recorder = httptest.NewRecorder()
+		bot.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		var body BotHealth
+		require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		assert.True(t, body.Live)
+		assert.Equal(t, body.Ready, recorder.Code == http.StatusOK)
+	})
+}
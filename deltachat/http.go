@@ -0,0 +1,70 @@
+package deltachat
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// HttpRoundTripper is an http.RoundTripper that fetches resources with Rpc.GetHttpResponse(),
+// this way requests go through the core's network stack and respect the proxy settings of the account.
+//
+// Only GET requests are supported, request headers and body are not sent by the core.
+// The core doesn't report the status code, so successful responses have status 200 OK.
+//
+// Typical usage:
+//
+//	client := &http.Client{Transport: &deltachat.HttpRoundTripper{Rpc: rpc, AccountId: accId}}
+//	resp, err := client.Get("https://example.com")
+type HttpRoundTripper struct {
+	Rpc       *Rpc
+	AccountId AccountId
+}
+
+// UnsupportedMethodErr is returned by HttpRoundTripper for requests that are not GET requests.
+type UnsupportedMethodErr struct {
+	Method string
+}
+
+func (self *UnsupportedMethodErr) Error() string {
+	return fmt.Sprintf("HTTP method %v not supported", self.Method)
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (self *HttpRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	if req.Method != "" && req.Method != http.MethodGet {
+		return nil, &UnsupportedMethodErr{Method: req.Method}
+	}
+
+	rpc := &Rpc{Context: req.Context(), Transport: self.Rpc.Transport}
+	resp, err := rpc.GetHttpResponse(self.AccountId, req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	header := make(http.Header)
+	if resp.Mimetype != "" {
+		contentType := resp.Mimetype
+		if resp.Encoding != "" {
+			contentType += "; charset=" + resp.Encoding
+		}
+		header.Set("Content-Type", contentType)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(resp.Blob)))
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(resp.Blob)),
+		ContentLength: int64(len(resp.Blob)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package deltachat
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpResponse_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+	var resp HttpResponse
+	data := []byte(`{"blob":"aGVsbG8","mimetype":"text/plain","encoding":null}`)
+	require.Nil(t, json.Unmarshal(data, &resp))
+	assert.Equal(t, []byte("hello"), resp.Blob)
+	assert.Equal(t, "text/plain", resp.Mimetype)
+	assert.Empty(t, resp.Encoding)
+
+	// padded base64 is accepted too
+	data = []byte(`{"blob":"aGVsbG8=","mimetype":"text/plain","encoding":"utf-8"}`)
+	require.Nil(t, json.Unmarshal(data, &resp))
+	assert.Equal(t, []byte("hello"), resp.Blob)
+	assert.Equal(t, "utf-8", resp.Encoding)
+
+	data = []byte(`{"blob":"not base64!","mimetype":"text/plain","encoding":null}`)
+	assert.NotNil(t, json.Unmarshal(data, &resp))
+}
+
+func TestHttpRoundTripper_UnsupportedMethod(t *testing.T) {
+	t.Parallel()
+	client := &http.Client{Transport: &HttpRoundTripper{Rpc: &Rpc{}}}
+	_, err := client.Post("http://localhost/", "text/plain", strings.NewReader("test"))
+	var methodErr *UnsupportedMethodErr
+	assert.ErrorAs(t, err, &methodErr)
+}
+
+func TestHttpRoundTripper(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html>test</html>")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		resp, err := rpc.GetHttpResponse(accId, server.URL)
+		require.Nil(t, err)
+		assert.Equal(t, "text/html", resp.Mimetype)
+		assert.Equal(t, "<html>test</html>", string(resp.Blob))
+
+		client := &http.Client{Transport: &HttpRoundTripper{Rpc: rpc, AccountId: accId}}
+		httpResp, err := client.Get(server.URL)
+		require.Nil(t, err)
+		defer httpResp.Body.Close()
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.True(t, strings.HasPrefix(httpResp.Header.Get("Content-Type"), "text/html"))
+		body, err := io.ReadAll(httpResp.Body)
+		require.Nil(t, err)
+		assert.Equal(t, "<html>test</html>", string(body))
+	})
+}
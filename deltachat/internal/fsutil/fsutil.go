@@ -0,0 +1,25 @@
+// Package fsutil contains file system helpers shared by the deltachat packages.
+package fsutil
+
+import (
+	"io"
+	"os"
+)
+
+// Copy the file at src to dst, dst is overwritten if it exists.
+func CopyFile(src string, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		return err
+	}
+	return dstFile.Close()
+}
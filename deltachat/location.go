@@ -0,0 +1,119 @@
+package deltachat
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type _Gpx struct {
+	XMLName   xml.Name    `xml:"gpx"`
+	Version   string      `xml:"version,attr"`
+	Creator   string      `xml:"creator,attr"`
+	Xmlns     string      `xml:"xmlns,attr"`
+	Waypoints []_GpxPoint `xml:"wpt"`
+	Tracks    []_GpxTrack `xml:"trk"`
+}
+
+type _GpxTrack struct {
+	Name    string      `xml:"name"`
+	Segment []_GpxPoint `xml:"trkseg>trkpt"`
+}
+
+type _GpxPoint struct {
+	Latitude  float64 `xml:"lat,attr"`
+	Longitude float64 `xml:"lon,attr"`
+	Time      string  `xml:"time"`
+	Name      string  `xml:"name,omitempty"`
+}
+
+// Write the given locations as a GPX 1.1 document.
+//
+// Locations are grouped in one track per contact, in the given order.
+// Independent locations (i.e. markers or single shared positions) are written as waypoints.
+func WriteGpx(writer io.Writer, locations []*Location) error {
+	gpx := _Gpx{Version: "1.1", Creator: "deltachat-rpc-client-go", Xmlns: "http://www.topografix.com/GPX/1/1"}
+	tracks := make(map[ContactId]int)
+	for _, loc := range locations {
+		point := _GpxPoint{
+			Latitude:  loc.Latitude,
+			Longitude: loc.Longitude,
+			Time:      loc.Timestamp.UTC().Format(time.RFC3339),
+		}
+		if loc.IsIndependent {
+			point.Name = loc.Marker
+			gpx.Waypoints = append(gpx.Waypoints, point)
+			continue
+		}
+		index, ok := tracks[loc.ContactId]
+		if !ok {
+			index = len(gpx.Tracks)
+			tracks[loc.ContactId] = index
+			gpx.Tracks = append(gpx.Tracks, _GpxTrack{Name: fmt.Sprintf("contact %v", loc.ContactId)})
+		}
+		gpx.Tracks[index].Segment = append(gpx.Tracks[index].Segment, point)
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	return encoder.Encode(gpx)
+}
+
+type _GeoJsonFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []_GeoJsonFeature `json:"features"`
+}
+
+type _GeoJsonFeature struct {
+	Type       string           `json:"type"`
+	Geometry   _GeoJsonGeometry `json:"geometry"`
+	Properties _GeoJsonLocation `json:"properties"`
+}
+
+type _GeoJsonGeometry struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type _GeoJsonLocation struct {
+	LocationId    uint64    `json:"locationId"`
+	ContactId     ContactId `json:"contactId"`
+	ChatId        ChatId    `json:"chatId"`
+	MsgId         MsgId     `json:"msgId,omitempty"`
+	Timestamp     string    `json:"timestamp"`
+	Accuracy      float64   `json:"accuracy"`
+	IsIndependent bool      `json:"isIndependent"`
+	Marker        string    `json:"marker,omitempty"`
+}
+
+// Write the given locations as a GeoJSON FeatureCollection with one Point feature per location.
+func WriteGeoJson(writer io.Writer, locations []*Location) error {
+	collection := _GeoJsonFeatureCollection{Type: "FeatureCollection", Features: []_GeoJsonFeature{}}
+	for _, loc := range locations {
+		collection.Features = append(collection.Features, _GeoJsonFeature{
+			Type: "Feature",
+			Geometry: _GeoJsonGeometry{
+				Type:        "Point",
+				Coordinates: [2]float64{loc.Longitude, loc.Latitude},
+			},
+			Properties: _GeoJsonLocation{
+				LocationId:    loc.LocationId,
+				ContactId:     loc.ContactId,
+				ChatId:        loc.ChatId,
+				MsgId:         loc.MsgId,
+				Timestamp:     loc.Timestamp.UTC().Format(time.RFC3339),
+				Accuracy:      loc.Accuracy,
+				IsIndependent: loc.IsIndependent,
+				Marker:        loc.Marker,
+			},
+		})
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
@@ -0,0 +1,94 @@
+package deltachat
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLocations = []*Location{
+	{LocationId: 1, Latitude: 52.5, Longitude: 13.4, Timestamp: Timestamp{time.Unix(1680779737, 0)}, ContactId: 10, ChatId: 12},
+	{LocationId: 2, Latitude: 52.6, Longitude: 13.5, Timestamp: Timestamp{time.Unix(1680779797, 0)}, ContactId: 10, ChatId: 12},
+	{LocationId: 3, Latitude: 48.1, Longitude: 11.5, Timestamp: Timestamp{time.Unix(1680779800, 0)}, ContactId: 11, ChatId: 12},
+	{LocationId: 4, Latitude: 48.2, Longitude: 11.6, Timestamp: Timestamp{time.Unix(1680779900, 0)}, ContactId: 11, ChatId: 12, IsIndependent: true, Marker: "🚚"},
+}
+
+func TestWriteGpx(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.Nil(t, WriteGpx(&buf, testLocations))
+	gpx := buf.String()
+	assert.Contains(t, gpx, `<gpx version="1.1"`)
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("<trk>")))
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("<trkpt ")))
+	assert.Contains(t, gpx, `<wpt lat="48.2" lon="11.6">`)
+	assert.Contains(t, gpx, "<time>2023-04-06T11:15:37Z</time>")
+	assert.Contains(t, gpx, "<name>🚚</name>")
+}
+
+func TestWriteGeoJson(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.Nil(t, WriteGeoJson(&buf, testLocations))
+
+	var collection struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties map[string]any
+		}
+	}
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	require.Len(t, collection.Features, 4)
+	assert.Equal(t, "Point", collection.Features[0].Geometry.Type)
+	assert.Equal(t, []float64{13.4, 52.5}, collection.Features[0].Geometry.Coordinates)
+	assert.Equal(t, "🚚", collection.Features[3].Properties["marker"])
+
+	buf.Reset()
+	require.Nil(t, WriteGeoJson(&buf, nil))
+	assert.Contains(t, buf.String(), `"features": []`)
+}
+
+func TestRpc_Locations(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		chatId, err := rpc.CreateGroupChat(accId, "test group", false)
+		require.Nil(t, err)
+
+		sending, err := rpc.IsSendingLocationsToChat(accId, option.Some(chatId))
+		require.Nil(t, err)
+		assert.False(t, sending)
+
+		require.Nil(t, rpc.SendLocationsToChat(accId, chatId, 60))
+		sending, err = rpc.IsSendingLocationsToChat(accId, option.None[ChatId]())
+		require.Nil(t, err)
+		assert.True(t, sending)
+
+		streaming, err := rpc.SetLocation(accId, 52.5, 13.4, 10)
+		require.Nil(t, err)
+		assert.True(t, streaming)
+
+		locations, err := rpc.GetLocations(accId, option.Some(chatId), option.None[ContactId](), 0, 0)
+		require.Nil(t, err)
+		require.NotEmpty(t, locations)
+		loc := locations[0]
+		assert.Equal(t, chatId, loc.ChatId)
+		assert.Equal(t, ContactSelf, loc.ContactId)
+		assert.InDelta(t, 52.5, loc.Latitude, 0.0001)
+		assert.InDelta(t, 13.4, loc.Longitude, 0.0001)
+
+		require.Nil(t, rpc.SendLocationsToChat(accId, chatId, 0))
+		sending, err = rpc.IsSendingLocationsToChat(accId, option.Some(chatId))
+		require.Nil(t, err)
+		assert.False(t, sending)
+	})
+}
@@ -0,0 +1,211 @@
+package deltachat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/internal/fsutil"
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+)
+
+// Name of the metadata file written by MediaQuery.Export().
+const MediaMetadataFile = "metadata.json"
+
+// Message view types considered media by NewMediaQuery() if no type is given.
+var MediaTypes = []MsgType{MsgImage, MsgGif, MsgSticker, MsgAudio, MsgVoice, MsgVideo, MsgFile, MsgWebxdc}
+
+// MediaQuery selects messages with media, for example to show a gallery or archive the media of a chat.
+//
+// Typical usage:
+//
+//	msgIds, err := deltachat.NewMediaQuery(deltachat.MsgImage, deltachat.MsgVideo).InChat(chatId).Ids(rpc, accId)
+type MediaQuery struct {
+	types  []MsgType
+	chatId option.Option[ChatId]
+	since  time.Time
+	until  time.Time
+}
+
+// Create a new MediaQuery for messages with the given view types in all chats.
+// If no type is given, MediaTypes are used.
+func NewMediaQuery(types ...MsgType) *MediaQuery {
+	if len(types) == 0 {
+		types = MediaTypes
+	}
+	return &MediaQuery{types: types}
+}
+
+// Only select messages of the given chat.
+func (self *MediaQuery) InChat(chatId ChatId) *MediaQuery {
+	self.chatId = option.Some(chatId)
+	return self
+}
+
+// Select messages from all chats.
+func (self *MediaQuery) InAllChats() *MediaQuery {
+	self.chatId = option.None[ChatId]()
+	return self
+}
+
+// Only select messages sent in the given time range. A zero time leaves that side of the range open.
+func (self *MediaQuery) Between(since time.Time, until time.Time) *MediaQuery {
+	self.since = since
+	self.until = until
+	return self
+}
+
+// Get the IDs of the messages matching the query, in the order returned by the core, starting with the oldest message.
+func (self *MediaQuery) Ids(rpc *Rpc, accId AccountId) ([]MsgId, error) {
+	if self.since.IsZero() && self.until.IsZero() && len(self.types) <= 3 {
+		return self.getChatMedia(rpc, accId)
+	}
+	msgs, err := self.Messages(rpc, accId)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]MsgId, 0, len(msgs))
+	for _, msg := range msgs {
+		ids = append(ids, msg.Id)
+	}
+	return ids, nil
+}
+
+// Get the snapshots of the messages matching the query, in the order returned by the core, starting with the oldest message.
+func (self *MediaQuery) Messages(rpc *Rpc, accId AccountId) ([]*MsgSnapshot, error) {
+	ids, err := self.getChatMedia(rpc, accId)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := rpc.GetMessages(accId, ids)
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]*MsgSnapshot, 0, len(ids))
+	for _, id := range ids {
+		msg, ok := snapshots[id]
+		if !ok {
+			continue
+		}
+		if !self.since.IsZero() && msg.Timestamp.Before(self.since) {
+			continue
+		}
+		if !self.until.IsZero() && msg.Timestamp.After(self.until) {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(self.types) > 3 {
+		// merge the lists of the different core calls, each of them is already sorted
+		sortMedia(msgs)
+	}
+	return msgs, nil
+}
+
+// Metadata of a media file exported with MediaQuery.Export().
+type MediaMetadata struct {
+	MsgId       MsgId     `json:"msgId"`
+	ChatId      ChatId    `json:"chatId"`
+	FromId      ContactId `json:"fromId"`
+	FromAddress string    `json:"fromAddress,omitempty"`
+	FromName    string    `json:"fromName,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	ViewType    MsgType   `json:"viewType"`
+	Text        string    `json:"text,omitempty"`
+	FileName    string    `json:"fileName"`
+	FileMime    string    `json:"fileMime,omitempty"`
+	FileBytes   uint64    `json:"fileBytes"`
+	Path        string    `json:"path"` // Path of the exported file, relative to the export directory
+}
+
+// Copy the files of the messages matching the query into the given directory
+// and write their metadata as a JSON array into MediaMetadataFile in the same directory.
+// Exported files are named "<msgId>-<fileName>" to avoid collisions.
+func (self *MediaQuery) Export(rpc *Rpc, accId AccountId, dir string) ([]*MediaMetadata, error) {
+	msgs, err := self.Messages(rpc, accId)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*MediaMetadata, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.File == "" {
+			continue
+		}
+		fileName := msg.FileName
+		if fileName == "" {
+			fileName = filepath.Base(msg.File)
+		}
+		path := fmt.Sprintf("%v-%v", msg.Id, filepath.Base(fileName))
+		if err = fsutil.CopyFile(msg.File, filepath.Join(dir, path)); err != nil {
+			return nil, err
+		}
+		entry := &MediaMetadata{
+			MsgId:     msg.Id,
+			ChatId:    msg.ChatId,
+			FromId:    msg.FromId,
+			Timestamp: msg.Timestamp.Time,
+			ViewType:  msg.ViewType,
+			Text:      msg.Text,
+			FileName:  fileName,
+			FileMime:  msg.FileMime,
+			FileBytes: msg.FileBytes,
+			Path:      path,
+		}
+		if msg.Sender != nil {
+			entry.FromAddress = msg.Sender.Address
+			entry.FromName = msg.Sender.DisplayName
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return entries, os.WriteFile(filepath.Join(dir, MediaMetadataFile), data, 0o644)
+}
+
+// Get the IDs of the media messages, the result is only sorted if there are at most three types.
+func (self *MediaQuery) getChatMedia(rpc *Rpc, accId AccountId) ([]MsgId, error) {
+	var ids []MsgId
+	// the core supports at most three types per call
+	for start := 0; start < len(self.types); start += 3 {
+		batch := self.types[start:min(start+3, len(self.types))]
+		type2, type3 := option.None[MsgType](), option.None[MsgType]()
+		if len(batch) > 1 {
+			type2 = option.Some(batch[1])
+		}
+		if len(batch) > 2 {
+			type3 = option.Some(batch[2])
+		}
+		var batchIds []MsgId
+		var err error
+		if self.chatId.IsSome() {
+			batchIds, err = rpc.GetChatMedia(accId, self.chatId.Unwrap(), batch[0], type2, type3)
+		} else {
+			batchIds, err = rpc.GetAllChatsMedia(accId, batch[0], type2, type3)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, batchIds...)
+	}
+	return ids, nil
+}
+
+// Sort messages like the core sorts chat media: by sort timestamp, then by ID.
+func sortMedia(msgs []*MsgSnapshot) {
+	sort.Slice(msgs, func(i, j int) bool {
+		if !msgs[i].SortTimestamp.Equal(msgs[j].SortTimestamp.Time) {
+			return msgs[i].SortTimestamp.Before(msgs[j].SortTimestamp.Time)
+		}
+		return msgs[i].Id < msgs[j].Id
+	})
+}
@@ -0,0 +1,78 @@
+package deltachat
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortMedia(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+	msgs := []*MsgSnapshot{
+		{Id: 5, SortTimestamp: Timestamp{now}},
+		{Id: 3, SortTimestamp: Timestamp{now.Add(time.Second)}},
+		{Id: 4, SortTimestamp: Timestamp{now}},
+	}
+	sortMedia(msgs)
+	assert.Equal(t, MsgId(4), msgs[0].Id)
+	assert.Equal(t, MsgId(5), msgs[1].Id)
+	assert.Equal(t, MsgId(3), msgs[2].Id)
+}
+
+func TestMediaQuery(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		chatId, err := rpc.CreateGroupChat(accId, "test group", false)
+		require.Nil(t, err)
+		img := acfactory.TestImage()
+		msgId1, err := rpc.SendMsg(accId, chatId, MsgData{File: img, ViewType: MsgImage})
+		require.Nil(t, err)
+		_, err = rpc.MiscSendTextMessage(accId, chatId, "not media")
+		require.Nil(t, err)
+		msgId2, err := rpc.SendMsg(accId, chatId, MsgData{Text: "caption", File: img, ViewType: MsgImage})
+		require.Nil(t, err)
+
+		ids, err := NewMediaQuery(MsgImage).InChat(chatId).Ids(rpc, accId)
+		require.Nil(t, err)
+		assert.Equal(t, []MsgId{msgId1, msgId2}, ids)
+
+		msgs, err := rpc.GetMessages(accId, ids)
+		require.Nil(t, err)
+		require.Len(t, msgs, 2)
+		assert.Equal(t, "caption", msgs[msgId2].Text)
+
+		ids, err = NewMediaQuery().InAllChats().Ids(rpc, accId)
+		require.Nil(t, err)
+		assert.Contains(t, ids, msgId1)
+
+		ids, err = NewMediaQuery(MsgImage).InChat(chatId).Between(time.Now().Add(time.Hour), time.Time{}).Ids(rpc, accId)
+		require.Nil(t, err)
+		assert.Empty(t, ids)
+
+		prev, next, err := rpc.GetNeighboringChatMedia(accId, msgId1, MsgImage, option.None[MsgType](), option.None[MsgType]())
+		require.Nil(t, err)
+		assert.True(t, prev.IsNone())
+		assert.Equal(t, msgId2, next.Unwrap())
+
+		dir := filepath.Join(acfactory.MkdirTemp(), "export")
+		entries, err := NewMediaQuery().InChat(chatId).Export(rpc, accId, dir)
+		require.Nil(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "caption", entries[1].Text)
+		assert.FileExists(t, filepath.Join(dir, entries[0].Path))
+
+		data, err := os.ReadFile(filepath.Join(dir, MediaMetadataFile))
+		require.Nil(t, err)
+		var metadata []*MediaMetadata
+		require.Nil(t, json.Unmarshal(data, &metadata))
+		assert.Len(t, metadata, 2)
+		assert.Equal(t, msgId1, metadata[0].MsgId)
+	})
+}
@@ -0,0 +1,183 @@
+package deltachat
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// InvalidMsgErr is returned by MessageBuilder if the message is not valid and can't be sent.
+type InvalidMsgErr struct {
+	Reason string
+}
+
+func (self *InvalidMsgErr) Error() string {
+	return "invalid message: " + self.Reason
+}
+
+// Options set in a MessageBuilder.
+type msgOption uint
+
+const (
+	msgOptionText msgOption = 1 << iota
+	msgOptionHtml
+	msgOptionFile
+	msgOptionViewType
+	msgOptionQuote
+	msgOptionLocation
+	msgOptionSenderName
+)
+
+// MessageBuilder helps to compose messages with all the options supported by the core
+// and sends them with the most appropriate method.
+//
+// Overriding the sender avatar, setting the subject and protecting the quote are not supported
+// since the core's JSON-RPC API doesn't expose these message properties.
+//
+// Typical usage:
+//
+//	msgId, err := deltachat.NewMessageBuilder().Text("see attached").File("/tmp/report.pdf").Quote(msgId).Send(rpc, accId, chatId)
+type MessageBuilder struct {
+	data    MsgData
+	options msgOption
+}
+
+// Create a new empty MessageBuilder.
+func NewMessageBuilder() *MessageBuilder {
+	return &MessageBuilder{}
+}
+
+// Set the text of the message.
+func (self *MessageBuilder) Text(text string) *MessageBuilder {
+	self.data.Text = text
+	self.options |= msgOptionText
+	return self
+}
+
+// Set the HTML part of the message.
+func (self *MessageBuilder) Html(html string) *MessageBuilder {
+	self.data.Html = html
+	self.options |= msgOptionHtml
+	return self
+}
+
+// Attach the file at the given path to the message.
+func (self *MessageBuilder) File(path string) *MessageBuilder {
+	self.data.File = path
+	self.data.Filename = ""
+	self.options |= msgOptionFile
+	return self
+}
+
+// Attach the file at the given path to the message using a custom file name
+// instead of the name of the file in the file system.
+func (self *MessageBuilder) FileWithName(path string, filename string) *MessageBuilder {
+	self.data.File = path
+	self.data.Filename = filename
+	self.options |= msgOptionFile
+	return self
+}
+
+// Set the view type of the message. If not set, the core guesses it from the attached file.
+func (self *MessageBuilder) ViewType(viewType MsgType) *MessageBuilder {
+	self.data.ViewType = viewType
+	self.options |= msgOptionViewType
+	return self
+}
+
+// Quote the message with the given ID.
+func (self *MessageBuilder) Quote(msgId MsgId) *MessageBuilder {
+	self.data.QuotedMessageId = msgId
+	self.options |= msgOptionQuote
+	return self
+}
+
+// Quote the given text instead of an existing message.
+func (self *MessageBuilder) QuoteText(text string) *MessageBuilder {
+	self.data.QuotedText = text
+	self.options |= msgOptionQuote
+	return self
+}
+
+// Attach a location to the message.
+func (self *MessageBuilder) Location(latitude float64, longitude float64) *MessageBuilder {
+	self.data.Location = &[2]float64{latitude, longitude}
+	self.options |= msgOptionLocation
+	return self
+}
+
+// Override the sender name displayed to the recipients, useful for bridges and bots
+// relaying messages from other users.
+func (self *MessageBuilder) OverrideSenderName(name string) *MessageBuilder {
+	self.data.OverrideSenderName = name
+	self.options |= msgOptionSenderName
+	return self
+}
+
+// Check that the combination of options can be sent.
+func (self *MessageBuilder) Validate() error {
+	data := self.data
+	if data.Text == "" && data.Html == "" && data.File == "" && data.Location == nil {
+		return &InvalidMsgErr{Reason: "message is empty"}
+	}
+	if data.QuotedMessageId != 0 && data.QuotedText != "" {
+		return &InvalidMsgErr{Reason: "can not quote a message and a text at the same time"}
+	}
+	if data.Location != nil {
+		lat, lon := data.Location[0], data.Location[1]
+		if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return &InvalidMsgErr{Reason: fmt.Sprintf("invalid location: %v, %v", lat, lon)}
+		}
+	}
+	switch data.ViewType {
+	case "", MsgUnknown:
+	case MsgText:
+		if data.File != "" {
+			return &InvalidMsgErr{Reason: "text message with attached file"}
+		}
+	case MsgVideochatInvitation:
+		return &InvalidMsgErr{Reason: "video chat invitations must be sent with Rpc.SendVideoChatInvitation()"}
+	case MsgWebxdc:
+		if data.File == "" {
+			return &InvalidMsgErr{Reason: "webxdc message without file"}
+		}
+		name := data.Filename
+		if name == "" {
+			name = filepath.Base(data.File)
+		}
+		if !strings.HasSuffix(strings.ToLower(name), ".xdc") {
+			return &InvalidMsgErr{Reason: "webxdc file must have .xdc extension"}
+		}
+	default:
+		if data.File == "" {
+			return &InvalidMsgErr{Reason: fmt.Sprintf("%v message without file", data.ViewType)}
+		}
+	}
+	return nil
+}
+
+// Validate the message and return the resulting MsgData.
+func (self *MessageBuilder) Build() (MsgData, error) {
+	if err := self.Validate(); err != nil {
+		return MsgData{}, err
+	}
+	return self.data, nil
+}
+
+// Validate and send the message to the given chat.
+//
+// Plain text messages are sent with Rpc.MiscSendTextMessage(), stickers without other options
+// with Rpc.SendSticker() and any other message with Rpc.SendMsg().
+func (self *MessageBuilder) Send(rpc *Rpc, accId AccountId, chatId ChatId) (MsgId, error) {
+	data, err := self.Build()
+	if err != nil {
+		return 0, err
+	}
+	if self.options == msgOptionText {
+		return rpc.MiscSendTextMessage(accId, chatId, data.Text)
+	}
+	if self.options == msgOptionViewType|msgOptionFile && data.ViewType == MsgSticker && data.Filename == "" {
+		return rpc.SendSticker(accId, chatId, data.File)
+	}
+	return rpc.SendMsg(accId, chatId, data)
+}
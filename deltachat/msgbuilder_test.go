@@ -0,0 +1,84 @@
+package deltachat
+
+import (
+	"testing"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBuilder_Validate(t *testing.T) {
+	t.Parallel()
+	var invalidErr *InvalidMsgErr
+	assert.ErrorAs(t, NewMessageBuilder().Validate(), &invalidErr)
+	assert.ErrorAs(t, NewMessageBuilder().ViewType(MsgSticker).Validate(), &invalidErr)
+	assert.ErrorAs(t, NewMessageBuilder().Text("hi").ViewType(MsgImage).Validate(), &invalidErr)
+	assert.ErrorAs(t, NewMessageBuilder().Text("hi").File("test.txt").ViewType(MsgText).Validate(), &invalidErr)
+	assert.ErrorAs(t, NewMessageBuilder().File("app.zip").ViewType(MsgWebxdc).Validate(), &invalidErr)
+	assert.ErrorAs(t, NewMessageBuilder().Text("hi").ViewType(MsgVideochatInvitation).Validate(), &invalidErr)
+	assert.ErrorAs(t, NewMessageBuilder().Location(91, 0).Validate(), &invalidErr)
+	assert.ErrorAs(t, NewMessageBuilder().Text("hi").Quote(1).QuoteText("quote").Validate(), &invalidErr)
+
+	assert.Nil(t, NewMessageBuilder().Text("hi").Validate())
+	assert.Nil(t, NewMessageBuilder().Location(52.5, 13.4).Validate())
+	assert.Nil(t, NewMessageBuilder().FileWithName("/tmp/abc", "app.xdc").ViewType(MsgWebxdc).Validate())
+	assert.Nil(t, NewMessageBuilder().File("sticker.png").ViewType(MsgSticker).Validate())
+
+	data, err := NewMessageBuilder().Text("hi").Html("<b>hi</b>").FileWithName("/tmp/abc", "report.pdf").OverrideSenderName("alice").Build()
+	require.Nil(t, err)
+	assert.Equal(t, MsgData{Text: "hi", Html: "<b>hi</b>", File: "/tmp/abc", Filename: "report.pdf", OverrideSenderName: "alice"}, data)
+
+	data, err = NewMessageBuilder().FileWithName("/tmp/abc", "report.pdf").File("/tmp/def").Build()
+	require.Nil(t, err)
+	assert.Empty(t, data.Filename)
+}
+
+func TestMessageBuilder_options(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, msgOptionText, NewMessageBuilder().Text("hi").options)
+	assert.Equal(t, msgOptionViewType|msgOptionFile, NewMessageBuilder().File("sticker.png").ViewType(MsgSticker).options)
+	assert.Equal(t, msgOptionText|msgOptionQuote, NewMessageBuilder().Text("hi").QuoteText("quote").options)
+}
+
+func TestMessageBuilder_Send(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		chatId, err := rpc.CreateGroupChat(accId, "test group", false)
+		require.Nil(t, err)
+
+		msgId, err := NewMessageBuilder().Text("hi").Send(rpc, accId, chatId)
+		require.Nil(t, err)
+
+		msgId2, err := NewMessageBuilder().Text("reply").Quote(msgId).OverrideSenderName("alice").Send(rpc, accId, chatId)
+		require.Nil(t, err)
+		msg, err := rpc.GetMessage(accId, msgId2)
+		require.Nil(t, err)
+		assert.Equal(t, "reply", msg.Text)
+		assert.Equal(t, msgId, msg.Quote.MessageId)
+		assert.Equal(t, "alice", msg.OverrideSenderName)
+
+		msgId3, err := NewMessageBuilder().File(acfactory.TestImage()).ViewType(MsgSticker).Send(rpc, accId, chatId)
+		require.Nil(t, err)
+		msg, err = rpc.GetMessage(accId, msgId3)
+		require.Nil(t, err)
+		assert.Equal(t, MsgSticker, msg.ViewType)
+
+		_, err = NewMessageBuilder().ViewType(MsgImage).Send(rpc, accId, chatId)
+		assert.IsType(t, &InvalidMsgErr{}, err)
+	})
+}
+
+func TestRpc_MiscSendMsg(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		chatId, err := rpc.CreateGroupChat(accId, "test group", false)
+		require.Nil(t, err)
+
+		msgId, msg, err := rpc.MiscSendMsg(accId, chatId, option.Some("hi"), option.None[string](), option.Some([2]float64{52.5, 13.4}), option.None[MsgId]())
+		require.Nil(t, err)
+		assert.Equal(t, msgId, msg.Id)
+		assert.Equal(t, "hi", msg.Text)
+		assert.True(t, msg.HasLocation)
+	})
+}
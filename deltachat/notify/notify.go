@@ -0,0 +1,166 @@
+// Package to build push notification bridges.
+//
+// A Notifier listens to incoming message events and produces ready-to-send
+// Notification payloads, skipping messages that should not be notified, like
+// messages in muted chats or contact requests.
+//
+// Typical usage:
+//
+//	notifier := notify.NewNotifier(func(bot *deltachat.Bot, n *notify.Notification) {
+//		http.Post("http://localhost/ntfy/topic", "text/plain", strings.NewReader(n.Body))
+//	})
+//	notifier.Register(bot)
+//	bot.Run()
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+)
+
+// Number of notified messages remembered per account to avoid duplicated notifications.
+const historySize = 1000
+
+// Notification payload for an incoming message.
+type Notification struct {
+	AccountId  deltachat.AccountId `json:"accountId"`
+	ChatId     deltachat.ChatId    `json:"chatId"`
+	MsgId      deltachat.MsgId     `json:"msgId"`
+	Title      string              `json:"title"`
+	Body       string              `json:"body"`
+	Image      string              `json:"image,omitempty"`      // Path of the attached image, if any
+	ChatAvatar string              `json:"chatAvatar,omitempty"` // Path of the chat's profile image, if any
+	GroupKey   string              `json:"groupKey"`             // Notifications of the same chat have the same GroupKey
+}
+
+// Handler called by Notifier for every message to notify.
+type Handler func(bot *deltachat.Bot, notification *Notification)
+
+// Notifier produces notifications for the incoming messages of a Bot.
+type Notifier struct {
+	// If true, messages in muted chats are notified.
+	IncludeMuted bool
+	// If true, messages in contact requests are notified.
+	IncludeContactRequests bool
+	// Called if there is an error building a notification, can be nil.
+	OnError func(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId, err error)
+
+	handler Handler
+	mutex   sync.Mutex
+	history map[deltachat.AccountId]*msgHistory
+}
+
+// Create a new Notifier that calls the given handler for every message to notify.
+func NewNotifier(handler Handler) *Notifier {
+	return &Notifier{handler: handler, history: make(map[deltachat.AccountId]*msgHistory)}
+}
+
+// Set the Notifier as the bot's EventHandler for EventIncomingMsg and EventIncomingMsgBunch.
+// This overrides any EventHandler previously set for those events with Bot.On(),
+// if you need your own handlers, call HandleEvent() from them instead.
+func (self *Notifier) Register(bot *deltachat.Bot) {
+	bot.On(deltachat.EventIncomingMsg{}, self.HandleEvent)
+	bot.On(deltachat.EventIncomingMsgBunch{}, self.HandleEvent)
+}
+
+// EventHandler producing notifications for EventIncomingMsg and EventIncomingMsgBunch events,
+// other events are ignored.
+func (self *Notifier) HandleEvent(bot *deltachat.Bot, accId deltachat.AccountId, event deltachat.Event) {
+	switch ev := event.(type) {
+	case deltachat.EventIncomingMsg:
+		self.notify(bot, accId, ev.MsgId)
+	case deltachat.EventIncomingMsgBunch:
+		for _, msgId := range ev.MsgIds {
+			self.notify(bot, accId, msgId)
+		}
+	}
+}
+
+// Build the notification for the given message.
+// If the message should not be notified according to the Notifier settings, nil is returned.
+func (self *Notifier) Build(rpc *deltachat.Rpc, accId deltachat.AccountId, msgId deltachat.MsgId) (*Notification, error) {
+	info, err := rpc.GetMessageNotificationInfo(accId, msgId)
+	if err != nil {
+		return nil, err
+	}
+	chat, err := rpc.GetBasicChatInfo(accId, info.ChatId)
+	if err != nil {
+		return nil, err
+	}
+	if (chat.IsMuted && !self.IncludeMuted) || (chat.IsContactRequest && !self.IncludeContactRequests) {
+		return nil, nil
+	}
+	return NewNotification(accId, info), nil
+}
+
+// Create a Notification from the given notification info.
+func NewNotification(accId deltachat.AccountId, info *deltachat.MsgNotificationInfo) *Notification {
+	body := info.SummaryText
+	if info.SummaryPrefix != "" {
+		body = strings.TrimSpace(info.SummaryPrefix + ": " + body)
+	}
+	return &Notification{
+		AccountId:  accId,
+		ChatId:     info.ChatId,
+		MsgId:      info.Id,
+		Title:      info.ChatName,
+		Body:       body,
+		Image:      info.Image,
+		ChatAvatar: info.ChatProfileImage,
+		GroupKey:   fmt.Sprintf("%v-%v", accId, info.ChatId),
+	}
+}
+
+func (self *Notifier) notify(bot *deltachat.Bot, accId deltachat.AccountId, msgId deltachat.MsgId) {
+	if !self.markNotified(accId, msgId) {
+		return
+	}
+	notification, err := self.Build(bot.Rpc, accId, msgId)
+	if err != nil {
+		if self.OnError != nil {
+			self.OnError(bot, accId, msgId, err)
+		}
+		return
+	}
+	if notification != nil {
+		self.handler(bot, notification)
+	}
+}
+
+// Remember the message as notified, returns false if the message was already notified.
+func (self *Notifier) markNotified(accId deltachat.AccountId, msgId deltachat.MsgId) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	history, ok := self.history[accId]
+	if !ok {
+		history = newMsgHistory(historySize)
+		self.history[accId] = history
+	}
+	return history.add(msgId)
+}
+
+// Fixed size set of the most recently added messages.
+type msgHistory struct {
+	ids   []deltachat.MsgId
+	set   map[deltachat.MsgId]bool
+	index int
+}
+
+func newMsgHistory(size int) *msgHistory {
+	return &msgHistory{ids: make([]deltachat.MsgId, size), set: make(map[deltachat.MsgId]bool, size)}
+}
+
+// Add the message to the history, returns false if it was already present.
+func (self *msgHistory) add(msgId deltachat.MsgId) bool {
+	if self.set[msgId] {
+		return false
+	}
+	delete(self.set, self.ids[self.index])
+	self.ids[self.index] = msgId
+	self.set[msgId] = true
+	self.index = (self.index + 1) % len(self.ids)
+	return true
+}
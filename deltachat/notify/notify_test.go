@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNotification(t *testing.T) {
+	t.Parallel()
+	info := &deltachat.MsgNotificationInfo{
+		Id:            12,
+		ChatId:        10,
+		ChatName:      "Friends",
+		SummaryPrefix: "Alice",
+		SummaryText:   "hello",
+	}
+	notification := NewNotification(1, info)
+	assert.Equal(t, "Friends", notification.Title)
+	assert.Equal(t, "Alice: hello", notification.Body)
+	assert.Equal(t, "1-10", notification.GroupKey)
+	assert.Equal(t, deltachat.MsgId(12), notification.MsgId)
+
+	info.SummaryPrefix = ""
+	assert.Equal(t, "hello", NewNotification(1, info).Body)
+}
+
+func TestMsgHistory(t *testing.T) {
+	t.Parallel()
+	history := newMsgHistory(2)
+	assert.True(t, history.add(1))
+	assert.False(t, history.add(1))
+	assert.True(t, history.add(2))
+	assert.True(t, history.add(3))
+	assert.True(t, history.add(1))
+	assert.False(t, history.add(3))
+}
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+	acfactory.WithRunningBot(func(bot *deltachat.Bot, botAcc deltachat.AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *deltachat.Rpc, accId deltachat.AccountId) {
+			notifications := make(chan *Notification, 10)
+			notifier := NewNotifier(func(bot *deltachat.Bot, notification *Notification) {
+				notifications <- notification
+			})
+			notifier.IncludeContactRequests = true
+			notifier.Register(bot)
+
+			chatWithBot := acfactory.CreateChat(accRpc, accId, bot.Rpc, botAcc)
+			_, err := accRpc.MiscSendTextMessage(accId, chatWithBot, "hello")
+			require.Nil(t, err)
+
+			notification := <-notifications
+			assert.Equal(t, botAcc, notification.AccountId)
+			assert.Contains(t, notification.Body, "hello")
+
+			info, err := bot.Rpc.GetMessageNotificationInfo(botAcc, notification.MsgId)
+			require.Nil(t, err)
+			assert.Equal(t, notification.ChatId, info.ChatId)
+		})
+	})
+}
@@ -0,0 +1,195 @@
+package deltachat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Security of an IMAP/SMTP connection.
+type Security int
+
+const (
+	SecurityAutomatic Security = iota
+	SecuritySsl
+	SecurityStartTls
+	SecurityPlain
+)
+
+func (self Security) String() string {
+	switch self {
+	case SecurityAutomatic:
+		return "Automatic"
+	case SecuritySsl:
+		return "SSL"
+	case SecurityStartTls:
+		return "STARTTLS"
+	case SecurityPlain:
+		return "Plain"
+	default:
+		return fmt.Sprintf("Security(%d)", int(self))
+	}
+}
+
+// Reason of a provisioning failure, see ProvisionErr.
+//
+// Failures reported by the core are untyped: the core only provides an error message,
+// so network, authentication and other configuration failures are all ProvisionFailureUnknown.
+type ProvisionFailure int
+
+const (
+	// The core could not configure the account or an RPC call failed, see ProvisionErr.Err for the error message.
+	ProvisionFailureUnknown ProvisionFailure = iota
+	// The configuration was rejected before configuring the account, ProvisionErr.Err is an InvalidConfigErr.
+	ProvisionFailureInvalidConfig
+	ProvisionFailureCancelled
+)
+
+func (self ProvisionFailure) String() string {
+	switch self {
+	case ProvisionFailureUnknown:
+		return "Unknown"
+	case ProvisionFailureInvalidConfig:
+		return "InvalidConfig"
+	case ProvisionFailureCancelled:
+		return "Cancelled"
+	default:
+		return fmt.Sprintf("ProvisionFailure(%d)", int(self))
+	}
+}
+
+// ProvisionErr is returned by Rpc.Provision() and Bot.Provision() if the account could not be configured.
+type ProvisionErr struct {
+	Reason ProvisionFailure
+	Err    error
+}
+
+func (self *ProvisionErr) Error() string {
+	return fmt.Sprintf("provisioning failed (%v): %v", self.Reason, self.Err)
+}
+
+func (self *ProvisionErr) Unwrap() error {
+	return self.Err
+}
+
+// Progress of a provisioning, see Bot.Provision().
+type ProvisionProgress struct {
+	AccountId AccountId
+	Permille  uint   // Progress in permille, 1000 when done
+	Comment   string // Optional comment from the core
+}
+
+// Get a progress callback for Bot.Provision() sending the progress to the given channel.
+// Progress is dropped if the channel is not ready to receive.
+func ProgressChan(channel chan<- ProvisionProgress) func(ProvisionProgress) {
+	return func(progress ProvisionProgress) {
+		select {
+		case channel <- progress:
+		default:
+		}
+	}
+}
+
+// Keeps the progress callbacks of the ongoing provisionings of a Bot.
+type provisionTracker struct {
+	mutex     sync.Mutex
+	callbacks map[AccountId]func(ProvisionProgress)
+}
+
+func (self *provisionTracker) set(accId AccountId, callback func(ProvisionProgress)) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if callback == nil {
+		delete(self.callbacks, accId)
+		return
+	}
+	if self.callbacks == nil {
+		self.callbacks = make(map[AccountId]func(ProvisionProgress))
+	}
+	self.callbacks[accId] = callback
+}
+
+func (self *provisionTracker) onEvent(accId AccountId, event Event) {
+	ev, ok := event.(EventConfigureProgress)
+	if !ok {
+		return
+	}
+	self.mutex.Lock()
+	callback := self.callbacks[accId]
+	self.mutex.Unlock()
+	if callback != nil {
+		callback(ProvisionProgress{AccountId: accId, Permille: ev.Progress, Comment: ev.Comment})
+	}
+}
+
+// Configure the given account with the given settings, AccountConfig.Addr is required.
+// Unset values are left to the core's automatic configuration.
+//
+// Progress is reported to the given callback, which can be nil, while the bot is running (see Bot.Run()).
+// See Rpc.Provision() for cancellation and errors.
+func (self *Bot) Provision(ctx context.Context, accId AccountId, config *AccountConfig, progress func(ProvisionProgress)) error {
+	self.provisions.set(accId, progress)
+	defer self.provisions.set(accId, nil)
+	return self.Rpc.Provision(ctx, accId, config)
+}
+
+// Configure the given account with the given settings, AccountConfig.Addr is required.
+// Unset values are left to the core's automatic configuration. Use Bot.Provision() to get the progress.
+//
+// If ctx is cancelled, the configuration is stopped with Rpc.StopOngoingProcess().
+// If the configuration fails, a ProvisionErr is returned.
+func (self *Rpc) Provision(ctx context.Context, accId AccountId, config *AccountConfig) error {
+	if err := ctx.Err(); err != nil {
+		return &ProvisionErr{Reason: ProvisionFailureCancelled, Err: err}
+	}
+	if err := self.validateProvisionConfig(config); err != nil {
+		reason := ProvisionFailureUnknown
+		var cfgErr *InvalidConfigErr
+		if errors.As(err, &cfgErr) {
+			reason = ProvisionFailureInvalidConfig
+		}
+		return &ProvisionErr{Reason: reason, Err: err}
+	}
+	// the configuration was already validated, so errors here come from the core or the transport
+	if err := self.SetAccountConfig(accId, config); err != nil {
+		return &ProvisionErr{Reason: ProvisionFailureUnknown, Err: err}
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		rpc := &Rpc{Context: context.Background(), Transport: self.Transport}
+		result <- rpc.Configure(accId)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		self.StopOngoingProcess(accId) //nolint:errcheck
+		<-result
+		return &ProvisionErr{Reason: ProvisionFailureCancelled, Err: ctx.Err()}
+	}
+	if err != nil {
+		return &ProvisionErr{Reason: ProvisionFailureUnknown, Err: err}
+	}
+	return nil
+}
+
+func (self *Rpc) validateProvisionConfig(config *AccountConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	addr := config.Addr.UnwrapOr("")
+	if addr == "" {
+		return &InvalidConfigErr{Key: "addr", Reason: "missing address"}
+	}
+	valid, err := self.CheckEmailValidity(addr)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return &InvalidConfigErr{Key: "addr", Value: addr, Reason: "invalid email address"}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package deltachat
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRpc_validateProvisionConfig(t *testing.T) {
+	t.Parallel()
+	rpc := &Rpc{}
+	var cfgErr *InvalidConfigErr
+	err := rpc.validateProvisionConfig(&AccountConfig{Addr: option.Some("bot@example.org"), MailPort: option.Some(70000)})
+	require.ErrorAs(t, err, &cfgErr)
+	assert.Equal(t, "mail_port", cfgErr.Key)
+	err = rpc.validateProvisionConfig(&AccountConfig{MailPassword: option.Some("secret")})
+	require.ErrorAs(t, err, &cfgErr)
+	assert.Equal(t, "addr", cfgErr.Key)
+}
+
+func TestProvisionErr(t *testing.T) {
+	t.Parallel()
+	coreErr := errors.New("Cannot login as \"bot@example.org\"")
+	var err error = &ProvisionErr{Reason: ProvisionFailureUnknown, Err: coreErr}
+	assert.ErrorIs(t, err, coreErr)
+	assert.Equal(t, "provisioning failed (Unknown): Cannot login as \"bot@example.org\"", err.Error())
+	assert.Equal(t, "Cancelled", ProvisionFailureCancelled.String())
+	assert.Equal(t, "ProvisionFailure(9)", ProvisionFailure(9).String())
+}
+
+func TestBot_Provision(t *testing.T) {
+	t.Parallel()
+	acfactory.WithUnconfiguredBot(func(bot *Bot, botAcc AccountId) {
+		go bot.Run() //nolint:errcheck
+		defer bot.Stop()
+
+		err := bot.Provision(context.Background(), botAcc, &AccountConfig{Addr: option.Some("invalid")}, nil)
+		var provErr *ProvisionErr
+		require.ErrorAs(t, err, &provErr)
+		assert.Equal(t, ProvisionFailureInvalidConfig, provErr.Reason)
+
+		addr, _ := bot.Rpc.GetConfig(botAcc, "addr")
+		pass, _ := bot.Rpc.GetConfig(botAcc, "mail_pw")
+		config := &AccountConfig{Addr: addr, MailPassword: pass, DisplayName: option.Some("provisioned"), Bot: option.Some(true)}
+		progress := make(chan ProvisionProgress, 1000)
+		require.Nil(t, bot.Provision(context.Background(), botAcc, config, ProgressChan(progress)))
+		assert.NotEmpty(t, progress)
+		configured, _ := bot.Rpc.IsConfigured(botAcc)
+		assert.True(t, configured)
+		name, _ := bot.Rpc.GetConfig(botAcc, "displayname")
+		assert.Equal(t, "provisioned", name.Unwrap())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err = bot.Provision(ctx, botAcc, config, nil)
+		require.ErrorAs(t, err, &provErr)
+		assert.Equal(t, ProvisionFailureCancelled, provErr.Reason)
+	})
+}
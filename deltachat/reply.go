@@ -0,0 +1,133 @@
+package deltachat
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Send a reply to the given message in the same chat, quoting it.
+func (self *Rpc) Reply(accountId AccountId, msg *MsgSnapshot, data MsgData) (MsgId, error) {
+	data.QuotedMessageId = msg.Id
+	return self.SendMsg(accountId, msg.ChatId, data)
+}
+
+// Send a reply quoting the given message in the 1:1 chat with its sender,
+// typically used to answer a group message privately.
+// The 1:1 chat is created if it doesn't exist yet.
+// An InvalidMsgErr is returned if the message was sent by this account or a special contact.
+func (self *Rpc) ReplyPrivately(accountId AccountId, msg *MsgSnapshot, data MsgData) (MsgId, error) {
+	if msg.FromId == ContactSelf {
+		return 0, &InvalidMsgErr{Reason: "can not reply privately to an own message"}
+	}
+	if msg.FromId <= ContactLastSpecial {
+		return 0, &InvalidMsgErr{Reason: fmt.Sprintf("can not reply privately to special contact %v", msg.FromId)}
+	}
+	chatId, err := self.CreateChatByContactId(accountId, msg.FromId)
+	if err != nil {
+		return 0, err
+	}
+	data.QuotedMessageId = msg.Id
+	return self.SendMsg(accountId, chatId, data)
+}
+
+// A message in a conversation tree built with Rpc.GetThread().
+type ThreadNode struct {
+	Msg     *MsgSnapshot
+	Parent  *ThreadNode
+	Replies []*ThreadNode
+}
+
+// Call the given function for this node and all its replies, depth-first.
+// The depth of the root node is zero. If the function returns false, the replies of that node are skipped.
+func (self *ThreadNode) Walk(callback func(node *ThreadNode, depth int) bool) {
+	self.walk(callback, 0)
+}
+
+func (self *ThreadNode) walk(callback func(node *ThreadNode, depth int) bool, depth int) {
+	if !callback(self, depth) {
+		return
+	}
+	for _, reply := range self.Replies {
+		reply.walk(callback, depth+1)
+	}
+}
+
+// Get the ID of the message the given message answers, or zero if it is not a reply.
+// The quoted message is preferred, MsgSnapshot.ParentId is used if there is no quote.
+func ThreadParentId(msg *MsgSnapshot) MsgId {
+	if msg.Quote != nil && msg.Quote.MessageId != 0 {
+		return msg.Quote.MessageId
+	}
+	return msg.ParentId
+}
+
+// Get the chain of messages the given message answers, starting with the root of the thread
+// and ending with the given message.
+func (self *Rpc) GetThreadAncestors(accountId AccountId, msgId MsgId) ([]*MsgSnapshot, error) {
+	var chain []*MsgSnapshot
+	visited := make(map[MsgId]bool)
+	for msgId != 0 && !visited[msgId] {
+		visited[msgId] = true
+		msg, err := self.GetMessage(accountId, msgId)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, msg)
+		msgId = ThreadParentId(msg)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// Reconstruct the conversation tree the given message belongs to.
+// The returned node is the root of the thread, only messages of the same chat are included.
+func (self *Rpc) GetThread(accountId AccountId, msgId MsgId) (*ThreadNode, error) {
+	ancestors, err := self.GetThreadAncestors(accountId, msgId)
+	if err != nil {
+		return nil, err
+	}
+	root := ancestors[0]
+	for _, msg := range ancestors {
+		if msg.ChatId == ancestors[len(ancestors)-1].ChatId {
+			root = msg
+			break
+		}
+	}
+
+	ids, err := self.GetMessageIds(accountId, root.ChatId, false, false)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := self.GetMessages(accountId, ids)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[MsgId][]*MsgSnapshot)
+	for _, msg := range msgs {
+		if parentId := ThreadParentId(msg); parentId != 0 && parentId != msg.Id {
+			children[parentId] = append(children[parentId], msg)
+		}
+	}
+
+	rootNode := &ThreadNode{Msg: root}
+	visited := map[MsgId]bool{root.Id: true}
+	queue := []*ThreadNode{rootNode}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		replies := children[node.Msg.Id]
+		sort.Slice(replies, func(i, j int) bool { return replies[i].Id < replies[j].Id })
+		for _, reply := range replies {
+			if visited[reply.Id] {
+				continue
+			}
+			visited[reply.Id] = true
+			child := &ThreadNode{Msg: reply, Parent: node}
+			node.Replies = append(node.Replies, child)
+			queue = append(queue, child)
+		}
+	}
+	return rootNode, nil
+}
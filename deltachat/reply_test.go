@@ -0,0 +1,110 @@
+package deltachat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreadParentId(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, MsgId(0), ThreadParentId(&MsgSnapshot{}))
+	assert.Equal(t, MsgId(2), ThreadParentId(&MsgSnapshot{ParentId: 2}))
+	assert.Equal(t, MsgId(3), ThreadParentId(&MsgSnapshot{ParentId: 2, Quote: &MsgQuote{MessageId: 3}}))
+	assert.Equal(t, MsgId(2), ThreadParentId(&MsgSnapshot{ParentId: 2, Quote: &MsgQuote{Text: "quote"}}))
+}
+
+func TestThreadNode_Walk(t *testing.T) {
+	t.Parallel()
+	root := &ThreadNode{Msg: &MsgSnapshot{Id: 1}}
+	child := &ThreadNode{Msg: &MsgSnapshot{Id: 2}, Parent: root}
+	root.Replies = []*ThreadNode{child, {Msg: &MsgSnapshot{Id: 4}, Parent: root}}
+	child.Replies = []*ThreadNode{{Msg: &MsgSnapshot{Id: 3}, Parent: child}}
+
+	var ids []MsgId
+	var depths []int
+	root.Walk(func(node *ThreadNode, depth int) bool {
+		ids = append(ids, node.Msg.Id)
+		depths = append(depths, depth)
+		return true
+	})
+	assert.Equal(t, []MsgId{1, 2, 3, 4}, ids)
+	assert.Equal(t, []int{0, 1, 2, 1}, depths)
+
+	ids = nil
+	root.Walk(func(node *ThreadNode, depth int) bool {
+		ids = append(ids, node.Msg.Id)
+		return depth == 0
+	})
+	assert.Equal(t, []MsgId{1, 2, 4}, ids)
+}
+
+func TestRpc_Reply(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		chatId, err := rpc.CreateGroupChat(accId, "test group", false)
+		require.Nil(t, err)
+		rootId, err := rpc.MiscSendTextMessage(accId, chatId, "root")
+		require.Nil(t, err)
+		root, err := rpc.GetMessage(accId, rootId)
+		require.Nil(t, err)
+
+		replyId, err := rpc.Reply(accId, root, MsgData{Text: "reply"})
+		require.Nil(t, err)
+		reply2, err := rpc.Account(accId).Message(replyId).Reply(MsgData{Text: "reply 2"})
+		require.Nil(t, err)
+
+		ancestors, err := rpc.GetThreadAncestors(accId, reply2.Id)
+		require.Nil(t, err)
+		require.Len(t, ancestors, 3)
+		assert.Equal(t, "root", ancestors[0].Text)
+		assert.Equal(t, "reply 2", ancestors[2].Text)
+
+		thread, err := rpc.GetThread(accId, replyId)
+		require.Nil(t, err)
+		assert.Equal(t, rootId, thread.Msg.Id)
+		require.Len(t, thread.Replies, 1)
+		assert.Equal(t, replyId, thread.Replies[0].Msg.Id)
+		require.Len(t, thread.Replies[0].Replies, 1)
+		assert.Equal(t, reply2.Id, thread.Replies[0].Replies[0].Msg.Id)
+
+		_, err = reply2.React("👍")
+		require.Nil(t, err)
+		reactions, err := reply2.Reactions()
+		require.Nil(t, err)
+		assert.Equal(t, 1, reactions.Unwrap().Reactions["👍"])
+	})
+}
+
+func TestRpc_ReplyPrivately(t *testing.T) {
+	t.Parallel()
+	var invalidErr *InvalidMsgErr
+	_, err := (&Rpc{}).ReplyPrivately(1, &MsgSnapshot{Id: 10, FromId: ContactSelf}, MsgData{Text: "hi"})
+	assert.ErrorAs(t, err, &invalidErr)
+	_, err = (&Rpc{}).ReplyPrivately(1, &MsgSnapshot{Id: 10, FromId: ContactDevice}, MsgData{Text: "hi"})
+	assert.ErrorAs(t, err, &invalidErr)
+
+	acfactory.WithOnlineAccount(func(rpc1 *Rpc, accId1 AccountId) {
+		acfactory.WithOnlineAccount(func(rpc2 *Rpc, accId2 AccountId) {
+			acfactory.IntroduceEachOther(rpc1, accId1, rpc2, accId2)
+			chatId := acfactory.CreateChat(rpc1, accId1, rpc2, accId2)
+			contacts, err := rpc1.GetChatContacts(accId1, chatId)
+			require.Nil(t, err)
+			groupId, err := rpc1.CreateGroupChat(accId1, "test group", false)
+			require.Nil(t, err)
+			require.Nil(t, rpc1.AddContactToChat(accId1, groupId, contacts[0]))
+			_, err = rpc1.MiscSendTextMessage(accId1, groupId, "hello group")
+			require.Nil(t, err)
+
+			msg := acfactory.NextMsg(rpc2, accId2)
+			assert.Equal(t, "hello group", msg.Text)
+			replyId, err := rpc2.ReplyPrivately(accId2, msg, MsgData{Text: "private reply"})
+			require.Nil(t, err)
+			reply, err := rpc2.GetMessage(accId2, replyId)
+			require.Nil(t, err)
+			assert.NotEqual(t, msg.ChatId, reply.ChatId)
+			assert.Equal(t, "hello group", reply.Quote.Text)
+		})
+	})
+}
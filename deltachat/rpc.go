@@ -2,6 +2,7 @@ package deltachat
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
 	"github.com/deltachat/deltachat-rpc-client-go/deltachat/transport"
@@ -538,8 +539,41 @@ func (self *Rpc) GetMessageHtml(accountId AccountId, msgId MsgId) (option.Option
 	return html, err
 }
 
-// TODO: get_messages
-// TODO: get_message_notification_info
+// Get the snapshots of the given messages in a single call.
+// Messages that could not be loaded are not included in the returned map.
+func (self *Rpc) GetMessages(accountId AccountId, msgIds []MsgId) (map[MsgId]*MsgSnapshot, error) {
+	var results map[MsgId]json.RawMessage
+	err := self.Transport.CallResult(self.Context, &results, "get_messages", accountId, msgIds)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make(map[MsgId]*MsgSnapshot, len(results))
+	for id, result := range results {
+		var kind struct{ Kind string }
+		if err = json.Unmarshal(result, &kind); err != nil {
+			return nil, err
+		}
+		if kind.Kind != "message" {
+			continue
+		}
+		var snapshot MsgSnapshot
+		if err = json.Unmarshal(result, &snapshot); err != nil {
+			return nil, err
+		}
+		snapshots[id] = &snapshot
+	}
+	return snapshots, nil
+}
+
+// Get the information needed to show a notification for the given message.
+func (self *Rpc) GetMessageNotificationInfo(accountId AccountId, msgId MsgId) (*MsgNotificationInfo, error) {
+	var info MsgNotificationInfo
+	err := self.Transport.CallResult(self.Context, &info, "get_message_notification_info", accountId, msgId)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
 
 // Delete messages. The messages are deleted on the current device and
 // on the IMAP server.
@@ -647,8 +681,20 @@ func (self *Rpc) GetContactIds(accountId AccountId, listFlags uint, query option
 	return ids, err
 }
 
-// TODO: get_contacts
-// TODO: get_contacts_by_ids
+// Get the snapshots of all contacts matching the given flags and query.
+// This is the same as GetContactIds() but returns ContactSnapshot objects instead of IDs.
+func (self *Rpc) GetContacts(accountId AccountId, listFlags uint, query option.Option[string]) ([]*ContactSnapshot, error) {
+	var contacts []*ContactSnapshot
+	err := self.Transport.CallResult(self.Context, &contacts, "get_contacts", accountId, listFlags, query)
+	return contacts, err
+}
+
+// Get the snapshots of the contacts with the given IDs in a single call.
+func (self *Rpc) GetContactsByIds(accountId AccountId, ids []ContactId) (map[ContactId]*ContactSnapshot, error) {
+	var contacts map[ContactId]*ContactSnapshot
+	err := self.Transport.CallResult(self.Context, &contacts, "get_contacts_by_ids", accountId, ids)
+	return contacts, err
+}
 
 func (self *Rpc) DeleteContact(accountId AccountId, contactId ContactId) error {
 	return self.Transport.Call(self.Context, "delete_contact", accountId, contactId)
@@ -697,16 +743,28 @@ func (self *Rpc) GetChatIdByContactId(accountId AccountId, contactId ContactId)
 // The list is already sorted and starts with the oldest message.
 // Clients should not try to re-sort the list as this would be an expensive action
 // and would result in inconsistencies between clients.
-//
-// Setting `chat_id` to `None` (`null` in typescript) means get messages with media
-// from any chat of the currently used account.
 func (self *Rpc) GetChatMedia(accountId AccountId, chatId ChatId, messageType MsgType, orMessageType2 option.Option[MsgType], orMessageType3 option.Option[MsgType]) ([]MsgId, error) {
 	var ids []MsgId
 	err := self.Transport.CallResult(self.Context, &ids, "get_chat_media", accountId, chatId, messageType, orMessageType2, orMessageType3)
 	return ids, err
 }
 
-// TODO: get_neighboring_chat_media
+// Like GetChatMedia() but get messages with media from all chats of the account.
+func (self *Rpc) GetAllChatsMedia(accountId AccountId, messageType MsgType, orMessageType2 option.Option[MsgType], orMessageType3 option.Option[MsgType]) ([]MsgId, error) {
+	var ids []MsgId
+	err := self.Transport.CallResult(self.Context, &ids, "get_chat_media", accountId, nil, messageType, orMessageType2, orMessageType3)
+	return ids, err
+}
+
+// Search the previous and next messages with media of the given types in the chat of the given message.
+// Typically used to implement "previous"/"next" buttons in a media gallery.
+//
+// Returns the IDs of the previous and next messages, option.None is returned if there is no such message.
+func (self *Rpc) GetNeighboringChatMedia(accountId AccountId, msgId MsgId, messageType MsgType, orMessageType2 option.Option[MsgType], orMessageType3 option.Option[MsgType]) (option.Option[MsgId], option.Option[MsgId], error) {
+	var ids [2]option.Option[MsgId]
+	err := self.Transport.CallResult(self.Context, &ids, "get_neighboring_chat_media", accountId, msgId, messageType, orMessageType2, orMessageType3)
+	return ids[0], ids[1], err
+}
 
 // ---------------------------------------------
 //                   backup
@@ -824,7 +882,45 @@ func (self *Rpc) GetConnectivityHtml(accountId AccountId) (string, error) {
 //                  locations
 // ---------------------------------------------
 
-// TODO: get_locations
+// Get the locations matching the given filters.
+//
+// If chatId is set, only locations shared in that chat are returned,
+// if contactId is set, only locations of that contact are returned.
+// timestampBegin and timestampEnd are Unix timestamps in seconds limiting the time range,
+// pass zero to leave the corresponding side of the range open.
+func (self *Rpc) GetLocations(accountId AccountId, chatId option.Option[ChatId], contactId option.Option[ContactId], timestampBegin int64, timestampEnd int64) ([]*Location, error) {
+	var locations []*Location
+	err := self.Transport.CallResult(self.Context, &locations, "get_locations", accountId, chatId, contactId, timestampBegin, timestampEnd)
+	return locations, err
+}
+
+// Enable or disable location streaming for a chat.
+//
+// Locations are sent to all members of the chat for the given number of seconds,
+// pass zero to stop sending locations to the chat.
+// The locations to stream are set with SetLocation().
+func (self *Rpc) SendLocationsToChat(accountId AccountId, chatId ChatId, seconds int64) error {
+	return self.Transport.Call(self.Context, "send_locations_to_chat", accountId, chatId, seconds)
+}
+
+// Check if location streaming is enabled for the given chat.
+// Pass option.None to check if location streaming is enabled for any chat.
+func (self *Rpc) IsSendingLocationsToChat(accountId AccountId, chatId option.Option[ChatId]) (bool, error) {
+	var sending bool
+	err := self.Transport.CallResult(self.Context, &sending, "is_sending_locations_to_chat", accountId, chatId)
+	return sending, err
+}
+
+// Set the current location of the device.
+//
+// The location is sent to all chats where location streaming is enabled with SendLocationsToChat().
+// Returns true if location streaming is still enabled for any chat,
+// if false is returned the device does not need to track its location anymore.
+func (self *Rpc) SetLocation(accountId AccountId, latitude float64, longitude float64, accuracy float64) (bool, error) {
+	var streaming bool
+	err := self.Transport.CallResult(self.Context, &streaming, "set_location", accountId, latitude, longitude, accuracy)
+	return streaming, err
+}
 
 // ---------------------------------------------
 //                   webxdc
@@ -856,7 +952,16 @@ func (self *Rpc) GetWebxdcBlob(accountId AccountId, msgId MsgId, path string) (s
 	return data, err
 }
 
-// TODO: get_http_response
+// Fetch the given URL using the core's network stack, honoring the proxy settings of the account.
+// This is used to provide internet access to webxdc apps with WebxdcMsgInfo.InternetAccess set.
+func (self *Rpc) GetHttpResponse(accountId AccountId, url string) (*HttpResponse, error) {
+	var response HttpResponse
+	err := self.Transport.CallResult(self.Context, &response, "get_http_response", accountId, url)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
 
 // Forward messages to another chat.
 //
@@ -946,9 +1051,25 @@ func (self *Rpc) SendVideoChatInvitation(accountId AccountId, chatId ChatId) (Ms
 //       that might get removed later again
 // ---------------------------------------------
 
-// TODO: misc_get_sticker_folder()
-// TODO: misc_save_sticker()
-// TODO: misc_get_stickers()
+// Get the path of the folder where the stickers of the account are stored.
+// Every subfolder is a sticker collection.
+func (self *Rpc) MiscGetStickerFolder(accountId AccountId) (string, error) {
+	var path string
+	err := self.Transport.CallResult(self.Context, &path, "misc_get_sticker_folder", accountId)
+	return path, err
+}
+
+// Save the sticker sent in the given message to the given sticker collection.
+func (self *Rpc) MiscSaveSticker(accountId AccountId, msgId MsgId, collection string) error {
+	return self.Transport.Call(self.Context, "misc_save_sticker", accountId, msgId, collection)
+}
+
+// Get the saved stickers, mapping collection names to the paths of their sticker files.
+func (self *Rpc) MiscGetStickers(accountId AccountId) (map[string][]string, error) {
+	var stickers map[string][]string
+	err := self.Transport.CallResult(self.Context, &stickers, "misc_get_stickers", accountId)
+	return stickers, err
+}
 
 // Send a text message and return the resulting Message instance.
 func (self *Rpc) MiscSendTextMessage(accountId AccountId, chatId ChatId, text string) (MsgId, error) {
@@ -957,7 +1078,25 @@ func (self *Rpc) MiscSendTextMessage(accountId AccountId, chatId ChatId, text st
 	return id, err
 }
 
-// TODO: misc_send_msg()
+// Send a message with the given optional text, file, location and quote.
+// Returns the ID and snapshot of the sent message.
+// Use SendMsg() or MessageBuilder for more options.
+func (self *Rpc) MiscSendMsg(accountId AccountId, chatId ChatId, text option.Option[string], file option.Option[string], location option.Option[[2]float64], quotedMessageId option.Option[MsgId]) (MsgId, *MsgSnapshot, error) {
+	var result [2]json.RawMessage
+	err := self.Transport.CallResult(self.Context, &result, "misc_send_msg", accountId, chatId, text, file, location, quotedMessageId)
+	if err != nil {
+		return 0, nil, err
+	}
+	var id MsgId
+	if err = json.Unmarshal(result[0], &id); err != nil {
+		return 0, nil, err
+	}
+	var snapshot MsgSnapshot
+	if err = json.Unmarshal(result[1], &snapshot); err != nil {
+		return id, nil, err
+	}
+	return id, &snapshot, nil
+}
 
 // mimics the old desktop call, will get replaced with something better in the composer rewrite,
 // the better version should support:
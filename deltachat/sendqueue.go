@@ -0,0 +1,394 @@
+package deltachat
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Priority of a message in a SendQueue, messages with higher priority are sent first.
+type Priority int
+
+const (
+	PriorityHigh   Priority = iota // For example replies to users
+	PriorityNormal                 // Default priority
+	PriorityLow                    // For example broadcasts
+)
+
+func (self Priority) String() string {
+	switch self {
+	case PriorityHigh:
+		return "High"
+	case PriorityNormal:
+		return "Normal"
+	case PriorityLow:
+		return "Low"
+	default:
+		return fmt.Sprintf("Priority(%d)", int(self))
+	}
+}
+
+// RateLimit of a token bucket: one message can be sent every Interval, with bursts of up to Burst messages.
+// A zero Interval means no limit.
+type RateLimit struct {
+	Interval time.Duration
+	Burst    int
+}
+
+// A message waiting in a SendQueue.
+type QueuedMsg struct {
+	Id        uint64
+	AccountId AccountId
+	ChatId    ChatId
+	Priority  Priority
+	Data      MsgData
+	Queued    time.Time
+
+	throttled bool
+	onSent    func(msgId MsgId, err error)
+}
+
+// Statistics of a SendQueue.
+type SendQueueMetrics struct {
+	Depth           int              // Number of messages waiting to be sent
+	DepthByPriority map[Priority]int // Number of messages waiting to be sent per priority
+	Sent            uint64           // Number of messages sent
+	Failed          uint64           // Number of messages that could not be sent
+	Throttled       uint64           // Number of messages that had to wait because of the rate limits
+	AvgWait         time.Duration    // Average time the sent messages waited in the queue
+}
+
+// SendQueue sends messages respecting per-account and per-chat rate limits.
+//
+// Set it with Bot.SetSendQueue(), the queue is processed while the bot is running.
+// The zero value is a queue without rate limits.
+//
+//	queue := deltachat.NewSendQueue(
+//		deltachat.RateLimit{Interval: time.Second, Burst: 10},     // per account
+//		deltachat.RateLimit{Interval: 5 * time.Second, Burst: 3}, // per chat
+//	)
+//	bot.SetSendQueue(queue)
+//	queue.Push(bot, accId, chatId, deltachat.MsgData{Text: "hi"}, deltachat.PriorityHigh)
+type SendQueue struct {
+	// Rate limit of each account.
+	AccountLimit RateLimit
+	// Rate limit of each chat.
+	ChatLimit RateLimit
+	// If true, queued messages are saved with Bot.Store(), one key per message,
+	// and sent after restarting the bot.
+	Persist bool
+	// Optional, called after sending each message, err is not nil if the message could not be sent.
+	OnSent func(bot *Bot, msg *QueuedMsg, msgId MsgId, err error)
+
+	mutex          sync.Mutex
+	items          []*QueuedMsg
+	lastId         uint64
+	accountBuckets map[AccountId]*tokenBucket
+	chatBuckets    map[chatKey]*tokenBucket
+	wake           chan struct{}
+	metrics        SendQueueMetrics
+	totalWait      time.Duration
+	now            func() time.Time
+}
+
+// Create a new SendQueue with the given rate limits.
+func NewSendQueue(accountLimit, chatLimit RateLimit) *SendQueue {
+	return &SendQueue{AccountLimit: accountLimit, ChatLimit: chatLimit}
+}
+
+// Set the queue used by the bot to send messages pushed with SendQueue.Push().
+// Must be called before Bot.Run().
+func (self *Bot) SetSendQueue(queue *SendQueue) {
+	self.sendQueue = queue
+}
+
+// Get the bot's SendQueue, nil if none was set.
+func (self *Bot) SendQueue() *SendQueue {
+	return self.sendQueue
+}
+
+// Add a message to the queue. Returns the ID of the queued message.
+func (self *SendQueue) Push(bot *Bot, accId AccountId, chatId ChatId, data MsgData, priority Priority) (uint64, error) {
+	return self.PushFunc(bot, accId, chatId, data, priority, nil)
+}
+
+// Add a message to the queue, onSent is called after sending it, err is not nil if the message
+// could not be sent. onSent is not persisted: if the bot is restarted before the message is sent,
+// the message is sent but onSent is not called. Returns the ID of the queued message.
+func (self *SendQueue) PushFunc(bot *Bot, accId AccountId, chatId ChatId, data MsgData, priority Priority, onSent func(msgId MsgId, err error)) (uint64, error) {
+	self.mutex.Lock()
+	self.init()
+	now := self.now()
+	id := max(self.lastId+1, uint64(now.UnixNano()))
+	self.lastId = id
+	msg := &QueuedMsg{Id: id, AccountId: accId, ChatId: chatId, Priority: priority, Data: data, Queued: now, onSent: onSent}
+	self.mutex.Unlock()
+
+	if self.Persist {
+		if err := self.store(bot, accId).Set(queueKey(id), msg); err != nil {
+			return 0, err
+		}
+	}
+	self.insert(msg)
+	return id, nil
+}
+
+// Remove a queued message that was not sent yet. Returns false if the message is not in the queue.
+func (self *SendQueue) Remove(bot *Bot, id uint64) (bool, error) {
+	self.mutex.Lock()
+	var removed *QueuedMsg
+	for i, item := range self.items {
+		if item.Id == id {
+			removed = item
+			self.items = append(self.items[:i], self.items[i+1:]...)
+			break
+		}
+	}
+	self.mutex.Unlock()
+	if removed == nil {
+		return false, nil
+	}
+	if self.Persist {
+		return true, self.store(bot, removed.AccountId).Delete(queueKey(id))
+	}
+	return true, nil
+}
+
+// Get the current queue statistics.
+func (self *SendQueue) Metrics() SendQueueMetrics {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	metrics := self.metrics
+	metrics.Depth = len(self.items)
+	metrics.DepthByPriority = make(map[Priority]int)
+	for _, item := range self.items {
+		metrics.DepthByPriority[item.Priority]++
+	}
+	if sent := metrics.Sent + metrics.Failed; sent > 0 {
+		metrics.AvgWait = self.totalWait / time.Duration(sent)
+	}
+	return metrics
+}
+
+// Load the messages persisted by a previous run.
+func (self *SendQueue) load(bot *Bot) {
+	if !self.Persist {
+		return
+	}
+	accIds, _ := bot.Rpc.GetAllAccountIds()
+	for _, accId := range accIds {
+		store := self.store(bot, accId)
+		keys, _ := store.Keys("")
+		for _, key := range keys {
+			var msg QueuedMsg
+			if found, err := store.Get(key, &msg); err != nil || !found || self.contains(msg.Id) {
+				continue
+			}
+			self.mutex.Lock()
+			self.lastId = max(self.lastId, msg.Id)
+			self.mutex.Unlock()
+			self.insert(&msg)
+		}
+	}
+}
+
+// Send the queued messages until the bot stops.
+func (self *SendQueue) run(bot *Bot, done <-chan struct{}) {
+	self.mutex.Lock()
+	self.init()
+	self.mutex.Unlock()
+	self.load(bot)
+	for {
+		msg, wait := self.next()
+		if msg != nil {
+			self.send(bot, msg)
+			continue
+		}
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = time.After(wait)
+		}
+		select {
+		case <-done:
+			return
+		case <-self.wake:
+		case <-timer:
+		}
+	}
+}
+
+func (self *SendQueue) send(bot *Bot, msg *QueuedMsg) {
+	msgId, err := bot.Rpc.SendMsg(msg.AccountId, msg.ChatId, msg.Data)
+	self.mutex.Lock()
+	self.init()
+	if err != nil {
+		self.metrics.Failed++
+	} else {
+		self.metrics.Sent++
+	}
+	self.totalWait += self.now().Sub(msg.Queued)
+	self.mutex.Unlock()
+	if self.Persist {
+		self.store(bot, msg.AccountId).Delete(queueKey(msg.Id)) //nolint:errcheck
+	}
+	if msg.onSent != nil {
+		msg.onSent(msgId, err)
+	}
+	if self.OnSent != nil {
+		self.OnSent(bot, msg, msgId, err)
+	}
+}
+
+// Take the next message that can be sent now, if there is none,
+// return the time to wait until a message can be sent, zero if the queue is empty.
+func (self *SendQueue) next() (*QueuedMsg, time.Duration) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.init()
+	if len(self.items) == 0 {
+		self.pruneBuckets()
+		return nil, 0
+	}
+	now := self.now()
+	var minWait time.Duration
+	for i, item := range self.items {
+		accBucket := self.accountBucket(item.AccountId, now)
+		chatBucket := self.chatBucket(item, now)
+		wait := max(accBucket.delay(now), chatBucket.delay(now))
+		if wait == 0 {
+			accBucket.take()
+			chatBucket.take()
+			self.items = append(self.items[:i], self.items[i+1:]...)
+			return item, 0
+		}
+		if !item.throttled {
+			item.throttled = true
+			self.metrics.Throttled++
+		}
+		if minWait == 0 || wait < minWait {
+			minWait = wait
+		}
+	}
+	return nil, minWait
+}
+
+func (self *SendQueue) insert(msg *QueuedMsg) {
+	self.mutex.Lock()
+	self.init()
+	self.items = append(self.items, msg)
+	sort.SliceStable(self.items, func(i, j int) bool {
+		if self.items[i].Priority != self.items[j].Priority {
+			return self.items[i].Priority < self.items[j].Priority
+		}
+		return self.items[i].Id < self.items[j].Id
+	})
+	self.mutex.Unlock()
+	select {
+	case self.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Initialize the internal state of a zero value SendQueue, the mutex must be held.
+func (self *SendQueue) init() {
+	if self.accountBuckets == nil {
+		self.accountBuckets = make(map[AccountId]*tokenBucket)
+		self.chatBuckets = make(map[chatKey]*tokenBucket)
+		self.wake = make(chan struct{}, 1)
+	}
+	if self.now == nil {
+		self.now = time.Now
+	}
+}
+
+func (self *SendQueue) contains(id uint64) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	for _, item := range self.items {
+		if item.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *SendQueue) accountBucket(accId AccountId, now time.Time) *tokenBucket {
+	bucket, ok := self.accountBuckets[accId]
+	if !ok {
+		bucket = newTokenBucket(self.AccountLimit, now)
+		self.accountBuckets[accId] = bucket
+	}
+	return bucket
+}
+
+func (self *SendQueue) chatBucket(msg *QueuedMsg, now time.Time) *tokenBucket {
+	key := chatKey{msg.AccountId, msg.ChatId}
+	bucket, ok := self.chatBuckets[key]
+	if !ok {
+		bucket = newTokenBucket(self.ChatLimit, now)
+		self.chatBuckets[key] = bucket
+	}
+	return bucket
+}
+
+// Forget the chat buckets that are full again, they are equivalent to new buckets.
+func (self *SendQueue) pruneBuckets() {
+	now := self.now()
+	for key, bucket := range self.chatBuckets {
+		if bucket.delay(now) == 0 && bucket.tokens >= float64(bucket.burst()) {
+			delete(self.chatBuckets, key)
+		}
+	}
+}
+
+func (self *SendQueue) store(bot *Bot, accId AccountId) *Store {
+	return bot.Store(accId).Namespace("sendqueue")
+}
+
+func queueKey(id uint64) string {
+	return fmt.Sprintf("%020d", id)
+}
+
+type chatKey struct {
+	accId  AccountId
+	chatId ChatId
+}
+
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	bucket := &tokenBucket{limit: limit, last: now}
+	bucket.tokens = float64(bucket.burst())
+	return bucket
+}
+
+func (self *tokenBucket) burst() int {
+	return max(self.limit.Burst, 1)
+}
+
+// Get the time to wait until a token is available.
+func (self *tokenBucket) delay(now time.Time) time.Duration {
+	if self.limit.Interval <= 0 {
+		return 0
+	}
+	if now.After(self.last) {
+		self.tokens += float64(now.Sub(self.last)) / float64(self.limit.Interval)
+		self.tokens = min(self.tokens, float64(self.burst()))
+		self.last = now
+	}
+	if self.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - self.tokens) * float64(self.limit.Interval))
+}
+
+func (self *tokenBucket) take() {
+	if self.limit.Interval > 0 {
+		self.tokens--
+	}
+}
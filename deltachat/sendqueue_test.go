@@ -0,0 +1,105 @@
+package deltachat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000, 0)
+	bucket := newTokenBucket(RateLimit{Interval: time.Second, Burst: 2}, now)
+	assert.Equal(t, time.Duration(0), bucket.delay(now))
+	bucket.take()
+	bucket.take()
+	assert.Equal(t, time.Second, bucket.delay(now))
+	assert.Equal(t, 500*time.Millisecond, bucket.delay(now.Add(500*time.Millisecond)))
+	assert.Equal(t, time.Duration(0), bucket.delay(now.Add(10*time.Second)))
+	assert.Equal(t, 2.0, bucket.tokens)
+
+	unlimited := newTokenBucket(RateLimit{}, now)
+	unlimited.take()
+	assert.Equal(t, time.Duration(0), unlimited.delay(now))
+}
+
+func TestSendQueue_next(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000, 0)
+	queue := NewSendQueue(RateLimit{}, RateLimit{Interval: time.Minute, Burst: 1})
+	queue.now = func() time.Time { return now }
+	queue.insert(&QueuedMsg{Id: 1, AccountId: 1, ChatId: 10, Priority: PriorityLow})
+	queue.insert(&QueuedMsg{Id: 2, AccountId: 1, ChatId: 10, Priority: PriorityHigh})
+	queue.insert(&QueuedMsg{Id: 3, AccountId: 1, ChatId: 11, Priority: PriorityNormal})
+	queue.insert(&QueuedMsg{Id: 4, AccountId: 1, ChatId: 10, Priority: PriorityHigh})
+
+	msg, _ := queue.next()
+	require.NotNil(t, msg)
+	assert.Equal(t, uint64(2), msg.Id)
+	// Chat 10 is throttled, the message to chat 11 can be sent.
+	msg, _ = queue.next()
+	require.NotNil(t, msg)
+	assert.Equal(t, uint64(3), msg.Id)
+	msg, wait := queue.next()
+	assert.Nil(t, msg)
+	assert.Equal(t, time.Minute, wait)
+
+	metrics := queue.Metrics()
+	assert.Equal(t, 2, metrics.Depth)
+	assert.Equal(t, map[Priority]int{PriorityHigh: 1, PriorityLow: 1}, metrics.DepthByPriority)
+	// Messages 1 and 4 waited, each message is counted once.
+	assert.Equal(t, uint64(2), metrics.Throttled)
+
+	now = now.Add(time.Minute)
+	msg, _ = queue.next()
+	require.NotNil(t, msg)
+	assert.Equal(t, uint64(4), msg.Id)
+}
+
+func TestSendQueue_zeroValue(t *testing.T) {
+	t.Parallel()
+	queue := &SendQueue{}
+	queue.insert(&QueuedMsg{Id: 1, AccountId: 1, ChatId: 10})
+	queue.insert(&QueuedMsg{Id: 2, AccountId: 1, ChatId: 10})
+	for _, id := range []uint64{1, 2} {
+		msg, _ := queue.next()
+		require.NotNil(t, msg)
+		assert.Equal(t, id, msg.Id)
+	}
+	msg, wait := queue.next()
+	assert.Nil(t, msg)
+	assert.Equal(t, time.Duration(0), wait)
+	assert.Equal(t, uint64(0), queue.Metrics().Throttled)
+}
+
+func TestBot_SetSendQueue(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineBot(func(bot *Bot, botAcc AccountId) {
+		acfactory.WithOnlineAccount(func(accRpc *Rpc, accId AccountId) {
+			sent := make(chan MsgId, 1)
+			queue := NewSendQueue(RateLimit{Interval: time.Second, Burst: 1}, RateLimit{})
+			queue.Persist = true
+			queue.OnSent = func(bot *Bot, msg *QueuedMsg, msgId MsgId, err error) {
+				assert.Nil(t, err)
+				sent <- msgId
+			}
+			bot.SetSendQueue(queue)
+			assert.Same(t, queue, bot.SendQueue())
+
+			chatId := acfactory.CreateChat(bot.Rpc, botAcc, accRpc, accId)
+			_, err := queue.Push(bot, botAcc, chatId, MsgData{Text: "queued"}, PriorityNormal)
+			require.Nil(t, err)
+			keys, err := bot.Store(botAcc).Namespace("sendqueue").Keys("")
+			require.Nil(t, err)
+			assert.Len(t, keys, 1)
+
+			go bot.Run() //nolint:errcheck
+			defer bot.Stop()
+			<-sent
+			assert.Equal(t, "queued", acfactory.NextMsg(accRpc, accId).Text)
+			assert.Equal(t, uint64(1), queue.Metrics().Sent)
+		})
+	})
+}
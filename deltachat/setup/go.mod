@@ -0,0 +1,24 @@
+module github.com/deltachat/deltachat-rpc-client-go/deltachat/setup
+
+go 1.21
+
+toolchain go1.21.0
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/deltachat/deltachat-rpc-client-go v0.17.1-0.20230731132031-99c0b7b46920
+	github.com/stretchr/testify v1.8.2
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/creachadair/jrpc2 v1.1.2 // indirect
+	github.com/creachadair/mds v0.8.2 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sync v0.6.0 // indirect
+)
+
+// this is needed to build against the code in this repository: the version required above lacks
+// AccountConfig and Rpc.Provision, bump it to the next tag of the root module when releasing
+replace github.com/deltachat/deltachat-rpc-client-go => ../../
@@ -0,0 +1,331 @@
+// Package setup configures the accounts of a bot from a declarative YAML, TOML or JSON file.
+//
+// Example YAML file:
+//
+//	remove_unlisted: false
+//	accounts:
+//	  - addr: bot@example.org
+//	    password: ${BOT_PASSWORD}
+//	    display_name: Echo Bot
+//	    avatar: ./avatar.png
+//	    bot: true
+//	    config:
+//	      mvbox_move: "0"
+//	      delete_server_after: "3600"
+//
+// References like ${BOT_PASSWORD} in string values are replaced with the value of the
+// environment variable, use $$ to get a literal $.
+//
+// The package is a separate Go module, so the YAML and TOML parsers are only
+// dependencies of the bots using it.
+package setup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+	"gopkg.in/yaml.v3"
+)
+
+// Format of a configuration file.
+type Format int
+
+const (
+	FormatYaml Format = iota
+	FormatToml
+	FormatJson
+)
+
+// Settings of an account. Accounts are identified by their address.
+type Account struct {
+	Addr        string            `json:"addr" yaml:"addr" toml:"addr"`
+	Password    string            `json:"password" yaml:"password" toml:"password"`
+	DisplayName string            `json:"display_name" yaml:"display_name" toml:"display_name"`
+	Avatar      string            `json:"avatar" yaml:"avatar" toml:"avatar"` // Path to the avatar image, relative to the file
+	Bot         *bool             `json:"bot" yaml:"bot" toml:"bot"`          // Mark the account as bot, true if not set
+	Config      map[string]string `json:"config" yaml:"config" toml:"config"` // Other config keys, see deltachat.AccountConfig
+}
+
+// Get the typed configuration of the account, without the credentials.
+func (self *Account) AccountConfig() (*deltachat.AccountConfig, error) {
+	config := &deltachat.AccountConfig{}
+	keys := deltachat.AccountConfigKeys()
+	raw := make(map[string]option.Option[string], len(self.Config))
+	for key, value := range self.Config {
+		if !slices.Contains(keys, key) {
+			return nil, fmt.Errorf("account %v: unknown config key %q", self.Addr, key)
+		}
+		raw[key] = option.Some(value)
+	}
+	if err := config.SetConfigMap(raw); err != nil {
+		return nil, fmt.Errorf("account %v: %w", self.Addr, err)
+	}
+	if self.DisplayName != "" {
+		config.DisplayName = option.Some(self.DisplayName)
+	}
+	if self.Avatar != "" {
+		config.SelfAvatar = option.Some(self.Avatar)
+	}
+	config.Bot = option.Some(self.Bot == nil || *self.Bot)
+	return config, config.Validate()
+}
+
+// Content of a configuration file.
+type File struct {
+	Accounts []Account `json:"accounts" yaml:"accounts" toml:"accounts"`
+	// If true, accounts not listed in the file are removed by Apply().
+	RemoveUnlisted bool `json:"remove_unlisted" yaml:"remove_unlisted" toml:"remove_unlisted"`
+}
+
+// Load the configuration file at the given path, the format is detected from the file extension.
+// Relative avatar paths are resolved from the file's directory.
+func Load(path string) (*File, error) {
+	var format Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = FormatYaml
+	case ".toml":
+		format = FormatToml
+	case ".json":
+		format = FormatJson
+	default:
+		return nil, fmt.Errorf("unknown configuration file format: %v", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	for i := range file.Accounts {
+		avatar := file.Accounts[i].Avatar
+		if avatar != "" && !filepath.IsAbs(avatar) {
+			file.Accounts[i].Avatar = filepath.Join(filepath.Dir(path), avatar)
+		}
+	}
+	return file, nil
+}
+
+// Parse a configuration file, environment variables are interpolated and the accounts are validated.
+func Parse(data []byte, format Format) (*File, error) {
+	file := &File{}
+	var err error
+	switch format {
+	case FormatYaml:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(file)
+		if errors.Is(err, io.EOF) { // empty file
+			err = nil
+		}
+	case FormatToml:
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), file)
+		if err == nil && len(meta.Undecoded()) != 0 {
+			err = fmt.Errorf("unknown field %q", meta.Undecoded()[0].String())
+		}
+	case FormatJson:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(file)
+	default:
+		err = fmt.Errorf("unknown format: %v", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = file.interpolate(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return file, file.Validate()
+}
+
+// Check that all accounts have a unique address and valid settings.
+func (self *File) Validate() error {
+	seen := make(map[string]bool)
+	for i := range self.Accounts {
+		acc := &self.Accounts[i]
+		if acc.Addr == "" {
+			return fmt.Errorf("account #%v: missing addr", i+1)
+		}
+		addr := strings.ToLower(acc.Addr)
+		if seen[addr] {
+			return fmt.Errorf("account %v: duplicated addr", acc.Addr)
+		}
+		seen[addr] = true
+		if _, err := acc.AccountConfig(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var envRegex = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Replace the environment variable references in all string values.
+func (self *File) interpolate(lookup func(string) (string, bool)) error {
+	var err error
+	expand := func(value string) string {
+		return envRegex.ReplaceAllStringFunc(value, func(match string) string {
+			if match == "$$" {
+				return "$"
+			}
+			name := match[2 : len(match)-1]
+			envValue, ok := lookup(name)
+			if !ok && err == nil {
+				err = fmt.Errorf("environment variable %v is not set", name)
+			}
+			return envValue
+		})
+	}
+	for i := range self.Accounts {
+		acc := &self.Accounts[i]
+		acc.Addr = expand(acc.Addr)
+		acc.Password = expand(acc.Password)
+		acc.DisplayName = expand(acc.DisplayName)
+		acc.Avatar = expand(acc.Avatar)
+		for key, value := range acc.Config {
+			acc.Config[key] = expand(value)
+		}
+	}
+	return err
+}
+
+// Changes done by Apply().
+type Result struct {
+	Created    []deltachat.AccountId // New accounts, only listed if they were configured successfully
+	Configured []deltachat.AccountId // Accounts (re)configured because they were new or their credentials changed
+	Updated    []deltachat.AccountId // Existing accounts whose settings were applied
+	Removed    []deltachat.AccountId
+}
+
+// Reconcile the bot accounts with the given file: missing accounts are created and configured,
+// existing accounts are updated, and unlisted accounts are removed if File.RemoveUnlisted is set.
+//
+// Accounts are configured with Bot.Provision(), ctx can be used to cancel the configuration.
+// If a new account can not be configured, it is removed again.
+// The returned Result lists the changes done, even if an error occurred.
+func Apply(ctx context.Context, bot *deltachat.Bot, file *File) (*Result, error) {
+	result := &Result{}
+	if err := file.Validate(); err != nil {
+		return result, err
+	}
+	existing, err := existingAccounts(bot.Rpc)
+	if err != nil {
+		return result, err
+	}
+
+	listed := make(map[deltachat.AccountId]bool)
+	for i := range file.Accounts {
+		acc := &file.Accounts[i]
+		accId, ok := existing[strings.ToLower(acc.Addr)]
+		if !ok {
+			if acc.Password == "" {
+				return result, fmt.Errorf("account %v: missing password", acc.Addr)
+			}
+			accId, err = bot.Rpc.AddAccount()
+			if err != nil {
+				return result, err
+			}
+		}
+		listed[accId] = true
+
+		configured, err := applyAccount(ctx, bot, accId, acc)
+		if err != nil {
+			err = fmt.Errorf("account %v: %w", acc.Addr, err)
+			if !ok {
+				err = errors.Join(err, bot.Rpc.RemoveAccount(accId))
+			}
+			return result, err
+		}
+		if !ok {
+			result.Created = append(result.Created, accId)
+		}
+		if configured {
+			result.Configured = append(result.Configured, accId)
+		} else if ok {
+			result.Updated = append(result.Updated, accId)
+		}
+	}
+
+	if file.RemoveUnlisted {
+		accIds, err := bot.Rpc.GetAllAccountIds()
+		if err != nil {
+			return result, err
+		}
+		for _, accId := range accIds {
+			if listed[accId] {
+				continue
+			}
+			if err = bot.Rpc.RemoveAccount(accId); err != nil {
+				return result, err
+			}
+			result.Removed = append(result.Removed, accId)
+		}
+	}
+	return result, nil
+}
+
+// Apply the settings of the given account, returns true if the account was (re)configured.
+// Unconfigured accounts require a password, configured accounts keep their password if it is empty.
+func applyAccount(ctx context.Context, bot *deltachat.Bot, accId deltachat.AccountId, acc *Account) (bool, error) {
+	config, err := acc.AccountConfig()
+	if err != nil {
+		return false, err
+	}
+	isConfigured, err := bot.Rpc.IsConfigured(accId)
+	if err != nil {
+		return false, err
+	}
+	if isConfigured {
+		password, err := bot.Rpc.GetConfig(accId, "mail_pw")
+		if err != nil {
+			return false, err
+		}
+		if acc.Password == "" || password.UnwrapOr("") == acc.Password {
+			return false, bot.Rpc.SetAccountConfig(accId, config)
+		}
+	} else if acc.Password == "" {
+		return false, fmt.Errorf("missing password")
+	}
+	config.Addr = option.Some(acc.Addr)
+	config.MailPassword = option.Some(acc.Password)
+	return true, bot.Provision(ctx, accId, config, nil)
+}
+
+// Get the existing accounts by lowercase address.
+func existingAccounts(rpc *deltachat.Rpc) (map[string]deltachat.AccountId, error) {
+	accIds, err := rpc.GetAllAccountIds()
+	if err != nil {
+		return nil, err
+	}
+	accounts := make(map[string]deltachat.AccountId, len(accIds))
+	for _, accId := range accIds {
+		values, err := rpc.BatchGetConfig(accId, []string{"configured_addr", "addr"})
+		if err != nil {
+			return nil, err
+		}
+		addr := values["configured_addr"].UnwrapOr("")
+		if addr == "" {
+			addr = values["addr"].UnwrapOr("")
+		}
+		if addr != "" {
+			accounts[strings.ToLower(addr)] = accId
+		}
+	}
+	return accounts, nil
+}
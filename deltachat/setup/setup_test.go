@@ -0,0 +1,141 @@
+package setup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	yamlData := `
+remove_unlisted: true
+accounts:
+  - addr: bot@example.org
+    password: secret
+    display_name: Echo Bot
+    config:
+      delete_server_after: "3600"
+`
+	tomlData := `
+remove_unlisted = true
+
+[[accounts]]
+addr = "bot@example.org"
+password = "secret"
+display_name = "Echo Bot"
+config = { delete_server_after = "3600" }
+`
+	jsonData := `{
+	"remove_unlisted": true,
+	"accounts": [{
+		"addr": "bot@example.org",
+		"password": "secret",
+		"display_name": "Echo Bot",
+		"config": {"delete_server_after": "3600"}
+	}]
+}`
+	inputs := map[Format]string{FormatYaml: yamlData, FormatToml: tomlData, FormatJson: jsonData}
+	for format, data := range inputs {
+		file, err := Parse([]byte(data), format)
+		require.Nil(t, err)
+		assert.True(t, file.RemoveUnlisted)
+		require.Len(t, file.Accounts, 1)
+		acc := file.Accounts[0]
+		assert.Equal(t, "bot@example.org", acc.Addr)
+		assert.Equal(t, "secret", acc.Password)
+		config, err := acc.AccountConfig()
+		require.Nil(t, err)
+		assert.Equal(t, "Echo Bot", config.DisplayName.Unwrap())
+		assert.Equal(t, time.Hour, config.DeleteServerAfter.Unwrap())
+		assert.True(t, config.Bot.Unwrap())
+	}
+
+	file, err := Parse(nil, FormatYaml)
+	require.Nil(t, err)
+	assert.Empty(t, file.Accounts)
+
+	_, err = Parse([]byte("accounts:\n  - addr: bot@example.org\n    unknown: 1\n"), FormatYaml)
+	assert.NotNil(t, err)
+	_, err = Parse([]byte("accounts:\n  - addr: bot@example.org\n    config: {foo: bar}\n"), FormatYaml)
+	assert.NotNil(t, err)
+	_, err = Parse([]byte("accounts:\n  - addr: bot@example.org\n    config: {mail_port: abc}\n"), FormatYaml)
+	assert.NotNil(t, err)
+	_, err = Parse([]byte("accounts:\n  - addr: bot@example.org\n  - addr: BOT@example.org\n"), FormatYaml)
+	assert.NotNil(t, err)
+}
+
+func TestFile_interpolate(t *testing.T) {
+	t.Parallel()
+	env := map[string]string{"BOT_PASSWORD": "secret"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	file := &File{Accounts: []Account{{Addr: "bot@example.org", Password: "${BOT_PASSWORD}$$1"}}}
+	require.Nil(t, file.interpolate(lookup))
+	assert.Equal(t, "secret$1", file.Accounts[0].Password)
+
+	file = &File{Accounts: []Account{{Addr: "bot@example.org", Password: "${MISSING}"}}}
+	assert.NotNil(t, file.interpolate(lookup))
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.yml")
+	require.Nil(t, os.WriteFile(path, []byte("accounts:\n  - addr: bot@example.org\n    avatar: avatar.png\n"), 0o600))
+	file, err := Load(path)
+	require.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "avatar.png"), file.Accounts[0].Avatar)
+
+	_, err = Load(filepath.Join(dir, "bot.ini"))
+	assert.NotNil(t, err)
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+	acfactory.WithUnconfiguredBot(func(bot *deltachat.Bot, botAcc deltachat.AccountId) {
+		addr, _ := bot.Rpc.GetConfig(botAcc, "addr")
+		pass, _ := bot.Rpc.GetConfig(botAcc, "mail_pw")
+		file := &File{Accounts: []Account{{Addr: addr.Unwrap(), Password: pass.Unwrap(), DisplayName: "Setup Bot"}}}
+
+		result, err := Apply(context.Background(), bot, file)
+		require.Nil(t, err)
+		assert.Empty(t, result.Created)
+		assert.Equal(t, []deltachat.AccountId{botAcc}, result.Configured)
+		name, _ := bot.Rpc.GetConfig(botAcc, "displayname")
+		assert.Equal(t, "Setup Bot", name.Unwrap())
+
+		// New accounts need a password.
+		missing := &File{Accounts: []Account{{Addr: "new@example.org"}}}
+		result, err = Apply(context.Background(), bot, missing)
+		assert.NotNil(t, err)
+		assert.Empty(t, result.Created)
+
+		// New accounts that fail to configure are removed.
+		invalid := &File{Accounts: []Account{{Addr: "invalid", Password: "secret"}}}
+		result, err = Apply(context.Background(), bot, invalid)
+		assert.NotNil(t, err)
+		assert.Empty(t, result.Created)
+		accIds, _ := bot.Rpc.GetAllAccountIds()
+		assert.Equal(t, []deltachat.AccountId{botAcc}, accIds)
+
+		unlisted, err := bot.Rpc.AddAccount()
+		require.Nil(t, err)
+		file.RemoveUnlisted = true
+		result, err = Apply(context.Background(), bot, file)
+		require.Nil(t, err)
+		assert.Empty(t, result.Configured)
+		assert.Equal(t, []deltachat.AccountId{botAcc}, result.Updated)
+		assert.Equal(t, []deltachat.AccountId{unlisted}, result.Removed)
+		accIds, _ = bot.Rpc.GetAllAccountIds()
+		assert.Equal(t, []deltachat.AccountId{botAcc}, accIds)
+	})
+}
@@ -0,0 +1,265 @@
+// Package to manage sticker packs and send stickers.
+//
+// A sticker pack is a sticker collection in the account's sticker folder
+// (see Rpc.MiscGetStickerFolder()), every image file in the collection is a sticker.
+// The name of a sticker is its file name without extension, emojis can be part of the name
+// to allow picking stickers by emoji, for example: "thumbs-up 👍.webp".
+package stickers
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+)
+
+// File extensions of the files that are imported as stickers.
+var Extensions = []string{".png", ".webp", ".gif", ".jpg", ".jpeg"}
+
+// InvalidPackNameErr is returned when trying to import a sticker pack with an invalid name.
+type InvalidPackNameErr struct {
+	Name string
+}
+
+func (self *InvalidPackNameErr) Error() string {
+	return fmt.Sprintf("invalid sticker pack name: %q", self.Name)
+}
+
+// DuplicateStickerErr is returned by ImportZip() if the archive contains several stickers
+// with the same file name in different directories.
+type DuplicateStickerErr struct {
+	FileName string
+}
+
+func (self *DuplicateStickerErr) Error() string {
+	return fmt.Sprintf("duplicate sticker file name in archive: %q", self.FileName)
+}
+
+// StickerNotFoundErr is returned by SendSticker() if no sticker matches the query.
+type StickerNotFoundErr struct {
+	Pack  string
+	Query string
+}
+
+func (self *StickerNotFoundErr) Error() string {
+	return fmt.Sprintf("no sticker matching %q in pack %q", self.Query, self.Pack)
+}
+
+// A single sticker of a sticker pack.
+type Sticker struct {
+	Name string
+	Path string
+}
+
+// A named collection of stickers.
+type Pack struct {
+	Name     string
+	Stickers []Sticker
+}
+
+// Find a sticker by name or emoji. Names are matched first, ignoring case,
+// if no sticker has the given name, the first sticker containing the query in its name is returned.
+func (self *Pack) Find(query string) (Sticker, bool) {
+	for _, sticker := range self.Stickers {
+		if strings.EqualFold(sticker.Name, query) {
+			return sticker, true
+		}
+	}
+	if query == "" {
+		return Sticker{}, false
+	}
+	for _, sticker := range self.Stickers {
+		if strings.Contains(sticker.Name, query) {
+			return sticker, true
+		}
+	}
+	return Sticker{}, false
+}
+
+// Get all the sticker packs of the given account, sorted by name.
+func ListPacks(rpc *deltachat.Rpc, accId deltachat.AccountId) ([]*Pack, error) {
+	collections, err := rpc.MiscGetStickers(accId)
+	if err != nil {
+		return nil, err
+	}
+	packs := make([]*Pack, 0, len(collections))
+	for name, paths := range collections {
+		packs = append(packs, newPack(name, paths))
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, nil
+}
+
+// Get the sticker pack with the given name. If the pack doesn't exist, nil is returned.
+func GetPack(rpc *deltachat.Rpc, accId deltachat.AccountId, name string) (*Pack, error) {
+	collections, err := rpc.MiscGetStickers(accId)
+	if err != nil {
+		return nil, err
+	}
+	paths, ok := collections[name]
+	if !ok {
+		return nil, nil
+	}
+	return newPack(name, paths), nil
+}
+
+// Import the stickers in the given directory as a sticker pack with the given name.
+// Subdirectories and files that are not images are ignored.
+// If a pack with the same name exists, the stickers are added to it.
+func ImportDir(rpc *deltachat.Rpc, accId deltachat.AccountId, name string, dir string) (*Pack, error) {
+	packDir, err := preparePackDir(rpc, accId, name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isSticker(entry.Name()) {
+			continue
+		}
+		src, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		path, err := copySticker(packDir, entry.Name(), src)
+		src.Close()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return newPack(name, paths), nil
+}
+
+// Import the stickers in the given zip archive as a sticker pack with the given name.
+// The directory structure inside the archive is ignored, only image files are imported.
+// If several image files have the same name, DuplicateStickerErr is returned and nothing is imported.
+// If a pack with the same name exists, the stickers are added to it.
+func ImportZip(rpc *deltachat.Rpc, accId deltachat.AccountId, name string, zipPath string) (*Pack, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	files := map[string]*zip.File{}
+	var fileNames []string
+	for _, file := range reader.File {
+		fileName := filepath.Base(filepath.FromSlash(file.Name))
+		if file.FileInfo().IsDir() || !isSticker(fileName) {
+			continue
+		}
+		key := strings.ToLower(fileName)
+		if _, ok := files[key]; ok {
+			return nil, &DuplicateStickerErr{FileName: fileName}
+		}
+		files[key] = file
+		fileNames = append(fileNames, fileName)
+	}
+
+	packDir, err := preparePackDir(rpc, accId, name)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, fileName := range fileNames {
+		src, err := files[strings.ToLower(fileName)].Open()
+		if err != nil {
+			return nil, err
+		}
+		path, err := copySticker(packDir, fileName, src)
+		src.Close()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return newPack(name, paths), nil
+}
+
+// Remove the sticker pack with the given name.
+func RemovePack(rpc *deltachat.Rpc, accId deltachat.AccountId, name string) error {
+	if !validPackName(name) {
+		return &InvalidPackNameErr{Name: name}
+	}
+	folder, err := rpc.MiscGetStickerFolder(accId)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(folder, name))
+}
+
+// Send the sticker matching the given name or emoji from the given pack.
+// If no sticker matches, StickerNotFoundErr is returned.
+func SendSticker(rpc *deltachat.Rpc, accId deltachat.AccountId, chatId deltachat.ChatId, pack string, query string) (deltachat.MsgId, error) {
+	stickerPack, err := GetPack(rpc, accId, pack)
+	if err != nil {
+		return 0, err
+	}
+	if stickerPack != nil {
+		if sticker, ok := stickerPack.Find(query); ok {
+			return rpc.SendSticker(accId, chatId, sticker.Path)
+		}
+	}
+	return 0, &StickerNotFoundErr{Pack: pack, Query: query}
+}
+
+func newPack(name string, paths []string) *Pack {
+	pack := &Pack{Name: name, Stickers: make([]Sticker, 0, len(paths))}
+	for _, path := range paths {
+		base := filepath.Base(path)
+		pack.Stickers = append(pack.Stickers, Sticker{
+			Name: strings.TrimSuffix(base, filepath.Ext(base)),
+			Path: path,
+		})
+	}
+	sort.Slice(pack.Stickers, func(i, j int) bool { return pack.Stickers[i].Name < pack.Stickers[j].Name })
+	return pack
+}
+
+func preparePackDir(rpc *deltachat.Rpc, accId deltachat.AccountId, name string) (string, error) {
+	if !validPackName(name) {
+		return "", &InvalidPackNameErr{Name: name}
+	}
+	folder, err := rpc.MiscGetStickerFolder(accId)
+	if err != nil {
+		return "", err
+	}
+	packDir := filepath.Join(folder, name)
+	return packDir, os.MkdirAll(packDir, 0o755)
+}
+
+func copySticker(packDir string, fileName string, src io.Reader) (string, error) {
+	path := filepath.Join(packDir, fileName)
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+	if _, err = io.Copy(dst, src); err != nil {
+		return "", err
+	}
+	return path, dst.Close()
+}
+
+func validPackName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+func isSticker(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, validExt := range Extensions {
+		if ext == validExt {
+			return true
+		}
+	}
+	return false
+}
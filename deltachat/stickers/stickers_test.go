@@ -0,0 +1,129 @@
+package stickers
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat"
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/internal/fsutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPack_Find(t *testing.T) {
+	t.Parallel()
+	pack := newPack("test", []string{"/stickers/test/Hello 👋.png", "/stickers/test/bye.webp"})
+	assert.Equal(t, "Hello 👋", pack.Stickers[0].Name)
+
+	sticker, ok := pack.Find("BYE")
+	assert.True(t, ok)
+	assert.Equal(t, "/stickers/test/bye.webp", sticker.Path)
+
+	sticker, ok = pack.Find("👋")
+	assert.True(t, ok)
+	assert.Equal(t, "Hello 👋", sticker.Name)
+
+	_, ok = pack.Find("unknown")
+	assert.False(t, ok)
+	_, ok = pack.Find("")
+	assert.False(t, ok)
+}
+
+func TestValidPackName(t *testing.T) {
+	t.Parallel()
+	assert.True(t, validPackName("branded"))
+	assert.False(t, validPackName(""))
+	assert.False(t, validPackName(".."))
+	assert.False(t, validPackName("a/b"))
+	assert.True(t, isSticker("image.PNG"))
+	assert.False(t, isSticker("readme.txt"))
+}
+
+func TestImportDir(t *testing.T) {
+	t.Parallel()
+	img := acfactory.TestImage()
+	dir := acfactory.MkdirTemp()
+	require.Nil(t, fsutil.CopyFile(img, filepath.Join(dir, "hello 👋.png")))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("ignored"), 0o644))
+
+	acfactory.WithOnlineAccount(func(rpc *deltachat.Rpc, accId deltachat.AccountId) {
+		_, err := ImportDir(rpc, accId, "../evil", dir)
+		assert.IsType(t, &InvalidPackNameErr{}, err)
+
+		pack, err := ImportDir(rpc, accId, "greetings", dir)
+		require.Nil(t, err)
+		require.Len(t, pack.Stickers, 1)
+
+		packs, err := ListPacks(rpc, accId)
+		require.Nil(t, err)
+		require.Len(t, packs, 1)
+		assert.Equal(t, "greetings", packs[0].Name)
+
+		chatId, err := rpc.CreateChatByContactId(accId, deltachat.ContactSelf)
+		require.Nil(t, err)
+		msgId, err := SendSticker(rpc, accId, chatId, "greetings", "👋")
+		require.Nil(t, err)
+		msg, err := rpc.GetMessage(accId, msgId)
+		require.Nil(t, err)
+		assert.Equal(t, deltachat.MsgSticker, msg.ViewType)
+
+		_, err = SendSticker(rpc, accId, chatId, "greetings", "unknown")
+		assert.IsType(t, &StickerNotFoundErr{}, err)
+
+		require.Nil(t, RemovePack(rpc, accId, "greetings"))
+		pack, err = GetPack(rpc, accId, "greetings")
+		require.Nil(t, err)
+		assert.Nil(t, pack)
+	})
+}
+
+func TestImportZip_duplicate(t *testing.T) {
+	t.Parallel()
+	zipPath := filepath.Join(t.TempDir(), "pack.zip")
+	zipFile, err := os.Create(zipPath)
+	require.Nil(t, err)
+	writer := zip.NewWriter(zipFile)
+	for _, name := range []string{"a/smile.png", "b/Smile.PNG"} {
+		_, err = writer.Create(name)
+		require.Nil(t, err)
+	}
+	require.Nil(t, writer.Close())
+	require.Nil(t, zipFile.Close())
+
+	// the archive is rejected before the account is accessed
+	_, err = ImportZip(nil, 1, "smileys", zipPath)
+	var dupErr *DuplicateStickerErr
+	require.ErrorAs(t, err, &dupErr)
+	assert.Equal(t, "Smile.PNG", dupErr.FileName)
+}
+
+func TestImportZip(t *testing.T) {
+	t.Parallel()
+	img, err := os.Open(acfactory.TestImage())
+	require.Nil(t, err)
+	defer img.Close()
+	zipPath := filepath.Join(acfactory.MkdirTemp(), "pack.zip")
+	zipFile, err := os.Create(zipPath)
+	require.Nil(t, err)
+	writer := zip.NewWriter(zipFile)
+	file, err := writer.Create("pack/../../smile.png")
+	require.Nil(t, err)
+	_, err = io.Copy(file, img)
+	require.Nil(t, err)
+	require.Nil(t, writer.Close())
+	require.Nil(t, zipFile.Close())
+
+	acfactory.WithOnlineAccount(func(rpc *deltachat.Rpc, accId deltachat.AccountId) {
+		pack, err := ImportZip(rpc, accId, "smileys", zipPath)
+		require.Nil(t, err)
+		require.Len(t, pack.Stickers, 1)
+		assert.Equal(t, "smile", pack.Stickers[0].Name)
+
+		folder, err := rpc.MiscGetStickerFolder(accId)
+		require.Nil(t, err)
+		assert.FileExists(t, filepath.Join(folder, "smileys", "smile.png"))
+	})
+}
@@ -0,0 +1,334 @@
+package deltachat
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+)
+
+// StoreBackend is the raw key-value storage used by Store.
+// Implement it to keep the bot data in an external database, for example a bbolt or sqlite file.
+type StoreBackend interface {
+	// Get the value of the given key, ok is false if the key doesn't exist.
+	Get(key string) (value string, ok bool, err error)
+	// Set the value of the given key.
+	Set(key string, value string) error
+	// Remove the given key, removing a key that doesn't exist is not an error.
+	Delete(key string) error
+	// Get all the keys starting with the given prefix.
+	Keys(prefix string) ([]string, error)
+}
+
+// Store is a persistent key-value store for bots, values are encoded as JSON.
+//
+// Operations are atomic for all the Store instances sharing the same backend in the same program,
+// i.e. all the stores returned by the same Bot.Store() and their namespaces.
+//
+// Typical usage:
+//
+//	settings := bot.Store(accId).Namespace("settings")
+//	settings.Set("lang", "en")
+//	var lang string
+//	found, err := settings.Get("lang", &lang)
+type Store struct {
+	backend   StoreBackend
+	mutex     *sync.Mutex
+	namespace string
+}
+
+// Create a new Store using the given backend.
+func NewStore(backend StoreBackend) *Store {
+	return &Store{backend: backend, mutex: &sync.Mutex{}}
+}
+
+// Get the Store of the given account, backed by the account's UI configuration (see SetUiConfig()).
+func (self *Bot) Store(accId AccountId) *Store {
+	self.storesMutex.Lock()
+	defer self.storesMutex.Unlock()
+	if self.stores == nil {
+		self.stores = make(map[AccountId]*Store)
+	}
+	store, ok := self.stores[accId]
+	if !ok {
+		store = NewStore(&UiConfigStoreBackend{Rpc: self.Rpc, AccountId: accId})
+		self.stores[accId] = store
+	}
+	return store
+}
+
+// Get a Store whose keys are isolated in the given namespace.
+// Namespaces can be nested, "/" separates them, so keys must not contain "/".
+func (self *Store) Namespace(name string) *Store {
+	return &Store{backend: self.backend, mutex: self.mutex, namespace: self.namespace + name + "/"}
+}
+
+// Decode the value of the given key into value. Returns false if the key doesn't exist.
+func (self *Store) Get(key string, value any) (bool, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	data, ok, err := self.backend.Get(self.namespace + key)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, json.Unmarshal([]byte(data), value)
+}
+
+// Encode the given value as JSON and save it in the given key.
+func (self *Store) Set(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.backend.Set(self.namespace+key, string(data))
+}
+
+// Remove the given key.
+func (self *Store) Delete(key string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.backend.Delete(self.namespace + key)
+}
+
+// Set the given key to newValue only if its current value is equal to oldValue.
+// If oldValue is nil, the key is only set if it doesn't exist.
+// Values are compared by their JSON encoding. Returns true if the value was set.
+func (self *Store) CompareAndSet(key string, oldValue any, newValue any) (bool, error) {
+	newData, err := json.Marshal(newValue)
+	if err != nil {
+		return false, err
+	}
+	var oldData []byte
+	if oldValue != nil {
+		if oldData, err = json.Marshal(oldValue); err != nil {
+			return false, err
+		}
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	current, ok, err := self.backend.Get(self.namespace + key)
+	if err != nil {
+		return false, err
+	}
+	if ok != (oldValue != nil) || (ok && !bytes.Equal([]byte(current), oldData)) {
+		return false, nil
+	}
+	return true, self.backend.Set(self.namespace+key, string(newData))
+}
+
+// Get the sorted keys starting with the given prefix.
+// The namespace is not part of the returned keys, keys of nested namespaces are not included.
+func (self *Store) Keys(prefix string) ([]string, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	backendKeys, err := self.backend.Keys(self.namespace + prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(backendKeys))
+	for _, key := range backendKeys {
+		key = strings.TrimPrefix(key, self.namespace)
+		if !strings.Contains(key, "/") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Call the given function with every key starting with the given prefix and its raw JSON value,
+// in key order. Iteration stops if the function returns false.
+func (self *Store) Range(prefix string, callback func(key string, value json.RawMessage) bool) error {
+	keys, err := self.Keys(prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		self.mutex.Lock()
+		data, ok, err := self.backend.Get(self.namespace + key)
+		self.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+		if ok && !callback(key, json.RawMessage(data)) {
+			break
+		}
+	}
+	return nil
+}
+
+// Prefix of the configuration keys used by UiConfigStoreBackend, "ui." is added by SetUiConfig().
+const uiStorePrefix = "store."
+
+// UiConfigStoreBackend stores the data in the account's UI configuration, see Bot.SetUiConfig().
+// The core can't list configuration keys, so the list of keys is kept in an extra configuration value
+// that is read and rewritten whenever a key is added or removed. This makes adding and removing keys
+// O(n) in the number of keys, and the list is only consistent if a single Store uses the account at a time:
+// several Bots or processes writing to the same account can lose keys from the list.
+// Use another backend for big data sets or shared accounts.
+type UiConfigStoreBackend struct {
+	Rpc       *Rpc
+	AccountId AccountId
+}
+
+func (self *UiConfigStoreBackend) Get(key string) (string, bool, error) {
+	value, err := self.Rpc.GetConfig(self.AccountId, "ui."+uiStorePrefix+key)
+	if err != nil || value.IsNone() {
+		return "", false, err
+	}
+	return value.Unwrap(), true, nil
+}
+
+func (self *UiConfigStoreBackend) Set(key string, value string) error {
+	index, err := self.index()
+	if err != nil {
+		return err
+	}
+	if err = self.Rpc.SetConfig(self.AccountId, "ui."+uiStorePrefix+key, option.Some(value)); err != nil {
+		return err
+	}
+	if !index[key] {
+		index[key] = true
+		return self.saveIndex(index)
+	}
+	return nil
+}
+
+func (self *UiConfigStoreBackend) Delete(key string) error {
+	index, err := self.index()
+	if err != nil {
+		return err
+	}
+	if err = self.Rpc.SetConfig(self.AccountId, "ui."+uiStorePrefix+key, option.None[string]()); err != nil {
+		return err
+	}
+	if index[key] {
+		delete(index, key)
+		return self.saveIndex(index)
+	}
+	return nil
+}
+
+func (self *UiConfigStoreBackend) Keys(prefix string) ([]string, error) {
+	index, err := self.index()
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for key := range index {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (self *UiConfigStoreBackend) index() (map[string]bool, error) {
+	index := make(map[string]bool)
+	value, err := self.Rpc.GetConfig(self.AccountId, "ui.store-index")
+	if err != nil || value.IsNone() || value.Unwrap() == "" {
+		return index, err
+	}
+	var keys []string
+	if err = json.Unmarshal([]byte(value.Unwrap()), &keys); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		index[key] = true
+	}
+	return index, nil
+}
+
+func (self *UiConfigStoreBackend) saveIndex(index map[string]bool) error {
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return self.Rpc.SetConfig(self.AccountId, "ui.store-index", option.Some(string(data)))
+}
+
+// FileStoreBackend keeps the data in a JSON file, the whole file is rewritten on every change.
+// It is meant for small amounts of data, use a database backend for bigger data sets.
+type FileStoreBackend struct {
+	Path string
+}
+
+func (self *FileStoreBackend) Get(key string) (string, bool, error) {
+	data, err := self.load()
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := data[key]
+	return value, ok, nil
+}
+
+func (self *FileStoreBackend) Set(key string, value string) error {
+	data, err := self.load()
+	if err != nil {
+		return err
+	}
+	data[key] = value
+	return self.save(data)
+}
+
+func (self *FileStoreBackend) Delete(key string) error {
+	data, err := self.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := data[key]; !ok {
+		return nil
+	}
+	delete(data, key)
+	return self.save(data)
+}
+
+func (self *FileStoreBackend) Keys(prefix string) ([]string, error) {
+	data, err := self.load()
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for key := range data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (self *FileStoreBackend) load() (map[string]string, error) {
+	data := make(map[string]string)
+	content, err := os.ReadFile(self.Path)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, json.Unmarshal(content, &data)
+}
+
+func (self *FileStoreBackend) save(data map[string]string) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	tmpPath := self.Path + ".tmp"
+	if err = os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, self.Path)
+}
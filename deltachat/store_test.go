@@ -0,0 +1,102 @@
+package deltachat
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStore(t *testing.T, store *Store) {
+	var value string
+	found, err := store.Get("missing", &value)
+	require.Nil(t, err)
+	assert.False(t, found)
+
+	require.Nil(t, store.Set("user.1", map[string]int{"score": 10}))
+	require.Nil(t, store.Set("user.2", map[string]int{"score": 20}))
+	require.Nil(t, store.Set("other", "value"))
+	var user map[string]int
+	found, err = store.Get("user.1", &user)
+	require.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 10, user["score"])
+
+	keys, err := store.Keys("user.")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"user.1", "user.2"}, keys)
+
+	var visited []string
+	err = store.Range("", func(key string, value json.RawMessage) bool {
+		visited = append(visited, key)
+		return len(visited) < 2
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"other", "user.1"}, visited)
+
+	ns := store.Namespace("settings")
+	require.Nil(t, ns.Set("other", "namespaced"))
+	found, err = ns.Get("other", &value)
+	require.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "namespaced", value)
+	found, _ = store.Get("other", &value)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+	keys, _ = ns.Keys("")
+	assert.Equal(t, []string{"other"}, keys)
+	// keys of nested namespaces are not listed by the parent
+	keys, _ = store.Keys("")
+	assert.Equal(t, []string{"other", "user.1", "user.2"}, keys)
+	visited = nil
+	err = store.Range("", func(key string, value json.RawMessage) bool {
+		visited = append(visited, key)
+		return true
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"other", "user.1", "user.2"}, visited)
+	require.Nil(t, ns.Namespace("nested").Set("key", 1))
+	keys, _ = ns.Keys("")
+	assert.Equal(t, []string{"other"}, keys)
+
+	ok, err := store.CompareAndSet("counter", nil, 1)
+	require.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = store.CompareAndSet("counter", nil, 1)
+	require.Nil(t, err)
+	assert.False(t, ok)
+	ok, _ = store.CompareAndSet("counter", 2, 3)
+	assert.False(t, ok)
+	ok, _ = store.CompareAndSet("counter", 1, 2)
+	assert.True(t, ok)
+	var counter int
+	store.Get("counter", &counter) //nolint:errcheck
+	assert.Equal(t, 2, counter)
+
+	require.Nil(t, store.Delete("user.1"))
+	require.Nil(t, store.Delete("user.1"))
+	keys, _ = store.Keys("user.")
+	assert.Equal(t, []string{"user.2"}, keys)
+}
+
+func TestStore_file(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "store.json")
+	testStore(t, NewStore(&FileStoreBackend{Path: path}))
+
+	var value int
+	found, err := NewStore(&FileStoreBackend{Path: path}).Get("counter", &value)
+	require.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 2, value)
+}
+
+func TestBot_Store(t *testing.T) {
+	t.Parallel()
+	acfactory.WithUnconfiguredBot(func(bot *Bot, accId AccountId) {
+		assert.Same(t, bot.Store(accId), bot.Store(accId))
+		testStore(t, bot.Store(accId))
+	})
+}
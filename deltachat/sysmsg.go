@@ -0,0 +1,133 @@
+package deltachat
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
+)
+
+// NotSysmsgErr is returned by Rpc.ParseSysmsg() if the message is not a system message.
+type NotSysmsgErr struct {
+	MsgId MsgId
+}
+
+func (self *NotSysmsgErr) Error() string {
+	return fmt.Sprintf("message %v is not a system message", self.MsgId)
+}
+
+// Metadata of a system message, see Rpc.ParseSysmsg().
+// Only the fields relevant to the message type are set.
+type SysmsgInfo struct {
+	Type   SysmsgType
+	MsgId  MsgId
+	ChatId ChatId
+	// Contact that caused the message, ContactSelf if it was caused by this account.
+	Actor ContactId
+	// SysmsgMemberAddedToGroup, SysmsgMemberRemovedFromGroup: the member that was added or removed,
+	// zero if unknown (core versions not providing MsgSnapshot.InfoContactId).
+	// If Target is equal to Actor in a SysmsgMemberRemovedFromGroup message, the member left the group.
+	Target ContactId
+	// SysmsgGroupNameChanged: the new name of the group.
+	// The core doesn't store it in the message, so it is only known (taken from the current chat state)
+	// if there is no later SysmsgGroupNameChanged message in the chat, None otherwise.
+	ChatName option.Option[string]
+	// SysmsgGroupImageChanged: path of the new group image, empty if the image was removed.
+	// Like ChatName, it is None if there is a later SysmsgGroupImageChanged message in the chat.
+	ChatImage option.Option[string]
+	// SysmsgEphemeralTimerChanged: the new ephemeral timer of the chat in seconds, 0 if disabled.
+	// Like ChatName, it is None if there is a later SysmsgEphemeralTimerChanged message in the chat.
+	EphemeralTimer option.Option[uint]
+	// SysmsgChatProtectionEnabled, SysmsgChatProtectionDisabled: whether the chat is now protected.
+	Protected bool
+	// SysmsgWebxdcInfoMessage, SysmsgWebxdcStatusUpdate: the webxdc instance the message belongs to.
+	WebxdcMsgId MsgId
+}
+
+// Return true if the message reports a member leaving the group by themselves.
+func (self *SysmsgInfo) IsLeave() bool {
+	return self.Type == SysmsgMemberRemovedFromGroup && self.Target != 0 && self.Target == self.Actor
+}
+
+// Get the metadata stored in the message itself.
+func newSysmsgInfo(msg *MsgSnapshot) (*SysmsgInfo, error) {
+	if !msg.IsInfo && (msg.SystemMessageType == "" || msg.SystemMessageType == SysmsgUnknown) {
+		return nil, &NotSysmsgErr{MsgId: msg.Id}
+	}
+	info := &SysmsgInfo{Type: msg.SystemMessageType, MsgId: msg.Id, ChatId: msg.ChatId, Actor: msg.FromId}
+	switch info.Type {
+	case "":
+		info.Type = SysmsgUnknown
+	case SysmsgMemberAddedToGroup, SysmsgMemberRemovedFromGroup:
+		info.Target = msg.InfoContactId
+	case SysmsgChatProtectionEnabled:
+		info.Protected = true
+	case SysmsgWebxdcInfoMessage, SysmsgWebxdcStatusUpdate:
+		info.WebxdcMsgId = msg.ParentId
+	}
+	return info, nil
+}
+
+// Extract the metadata of a system message without relying on its (translatable) text.
+//
+// Member changes are taken from MsgSnapshot.InfoContactId. The new group name, image and
+// ephemeral timer are not stored in the message, see SysmsgInfo for when they are known.
+func (self *Rpc) ParseSysmsg(accountId AccountId, msg *MsgSnapshot) (*SysmsgInfo, error) {
+	info, err := newSysmsgInfo(msg)
+	if err != nil {
+		return nil, err
+	}
+	if info.Type != SysmsgGroupNameChanged && info.Type != SysmsgGroupImageChanged && info.Type != SysmsgEphemeralTimerChanged {
+		return info, nil
+	}
+	latest, err := self.isLatestSysmsg(accountId, msg)
+	if err != nil || !latest {
+		return info, err
+	}
+
+	switch info.Type {
+	case SysmsgGroupNameChanged, SysmsgGroupImageChanged:
+		chat, err := self.GetBasicChatInfo(accountId, msg.ChatId)
+		if err != nil {
+			return nil, err
+		}
+		if info.Type == SysmsgGroupNameChanged {
+			info.ChatName = option.Some(chat.Name)
+		} else {
+			info.ChatImage = option.Some(chat.ProfileImage)
+		}
+	case SysmsgEphemeralTimerChanged:
+		timer, err := self.GetChatEphemeralTimer(accountId, msg.ChatId)
+		if err != nil {
+			return nil, err
+		}
+		info.EphemeralTimer = option.Some(timer)
+	}
+	return info, nil
+}
+
+// Return true if there is no later system message of the same type in the chat of the given message.
+func (self *Rpc) isLatestSysmsg(accountId AccountId, msg *MsgSnapshot) (bool, error) {
+	ids, err := self.GetMessageIds(accountId, msg.ChatId, true, false)
+	if err != nil {
+		return false, err
+	}
+	index := slices.Index(ids, msg.Id)
+	if index < 0 {
+		return false, nil
+	}
+	later := ids[index+1:]
+	if len(later) == 0 {
+		return true, nil
+	}
+	msgs, err := self.GetMessages(accountId, later)
+	if err != nil {
+		return false, err
+	}
+	for _, laterMsg := range msgs {
+		if laterMsg.SystemMessageType == msg.SystemMessageType {
+			return false, nil
+		}
+	}
+	return true, nil
+}
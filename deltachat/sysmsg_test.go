@@ -0,0 +1,91 @@
+package deltachat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysmsgInfo(t *testing.T) {
+	t.Parallel()
+	_, err := newSysmsgInfo(&MsgSnapshot{Id: 10, SystemMessageType: SysmsgUnknown})
+	var notSysmsgErr *NotSysmsgErr
+	require.ErrorAs(t, err, &notSysmsgErr)
+	assert.Equal(t, MsgId(10), notSysmsgErr.MsgId)
+
+	msg := &MsgSnapshot{Id: 11, ChatId: 12, FromId: 13, IsInfo: true, SystemMessageType: SysmsgMemberAddedToGroup, InfoContactId: 14}
+	info, err := newSysmsgInfo(msg)
+	require.Nil(t, err)
+	assert.Equal(t, &SysmsgInfo{Type: SysmsgMemberAddedToGroup, MsgId: 11, ChatId: 12, Actor: 13, Target: 14}, info)
+	assert.False(t, info.IsLeave())
+
+	msg = &MsgSnapshot{FromId: 13, IsInfo: true, SystemMessageType: SysmsgMemberRemovedFromGroup, InfoContactId: 13}
+	info, err = newSysmsgInfo(msg)
+	require.Nil(t, err)
+	assert.True(t, info.IsLeave())
+
+	info, err = newSysmsgInfo(&MsgSnapshot{IsInfo: true, SystemMessageType: SysmsgChatProtectionEnabled})
+	require.Nil(t, err)
+	assert.True(t, info.Protected)
+
+	info, err = newSysmsgInfo(&MsgSnapshot{IsInfo: true, SystemMessageType: SysmsgWebxdcInfoMessage, ParentId: 20})
+	require.Nil(t, err)
+	assert.Equal(t, MsgId(20), info.WebxdcMsgId)
+
+	info, err = newSysmsgInfo(&MsgSnapshot{IsInfo: true})
+	require.Nil(t, err)
+	assert.Equal(t, SysmsgUnknown, info.Type)
+
+	info, err = newSysmsgInfo(&MsgSnapshot{IsInfo: true, SystemMessageType: SysmsgMemberAddedToGroup})
+	require.Nil(t, err)
+	assert.Zero(t, info.Target) // unknown without InfoContactId
+}
+
+func TestRpc_ParseSysmsg(t *testing.T) {
+	t.Parallel()
+	acfactory.WithOnlineAccount(func(rpc *Rpc, accId AccountId) {
+		chatId, err := rpc.CreateGroupChat(accId, "test group", false)
+		require.Nil(t, err)
+		_, err = rpc.MiscSendTextMessage(accId, chatId, "hi")
+		require.Nil(t, err)
+
+		require.Nil(t, rpc.SetChatName(accId, chatId, "new name"))
+		msg := lastMsg(t, rpc, accId, chatId)
+		info, err := rpc.ParseSysmsg(accId, msg)
+		require.Nil(t, err)
+		assert.Equal(t, SysmsgGroupNameChanged, info.Type)
+		assert.Equal(t, "new name", info.ChatName.Unwrap())
+		assert.Equal(t, ContactSelf, info.Actor)
+
+		require.Nil(t, rpc.SetChatName(accId, chatId, "newer name"))
+		info, err = rpc.ParseSysmsg(accId, msg)
+		require.Nil(t, err)
+		assert.True(t, info.ChatName.IsNone()) // the name of the older message is unknown
+
+		require.Nil(t, rpc.SetChatEphemeralTimer(accId, chatId, 60))
+		msg = lastMsg(t, rpc, accId, chatId)
+		info, err = rpc.ParseSysmsg(accId, msg)
+		require.Nil(t, err)
+		assert.Equal(t, SysmsgEphemeralTimerChanged, info.Type)
+		assert.Equal(t, uint(60), info.EphemeralTimer.Unwrap())
+
+		contactId, err := rpc.CreateContact(accId, "null@example.org", "")
+		require.Nil(t, err)
+		require.Nil(t, rpc.AddContactToChat(accId, chatId, contactId))
+		msg = lastMsg(t, rpc, accId, chatId)
+		info, err = rpc.ParseSysmsg(accId, msg)
+		require.Nil(t, err)
+		assert.Equal(t, SysmsgMemberAddedToGroup, info.Type)
+		assert.Equal(t, contactId, info.Target)
+	})
+}
+
+func lastMsg(t *testing.T, rpc *Rpc, accId AccountId, chatId ChatId) *MsgSnapshot {
+	msgIds, err := rpc.GetMessageIds(accId, chatId, false, false)
+	require.Nil(t, err)
+	require.NotEmpty(t, msgIds)
+	msg, err := rpc.GetMessage(accId, msgIds[len(msgIds)-1])
+	require.Nil(t, err)
+	return msg
+}
@@ -1,6 +1,10 @@
 package deltachat
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
 	"github.com/deltachat/deltachat-rpc-client-go/deltachat/option"
 )
 
@@ -128,9 +132,11 @@ type MsgData struct {
 	Html               string      `json:"html,omitempty"`
 	ViewType           MsgType     `json:"viewtype,omitempty"`
 	File               string      `json:"file,omitempty"`
+	Filename           string      `json:"filename,omitempty"`
 	Location           *[2]float64 `json:"location,omitempty"`
 	OverrideSenderName string      `json:"overrideSenderName,omitempty"`
 	QuotedMessageId    MsgId       `json:"quotedMessageId,omitempty"`
+	QuotedText         string      `json:"quotedText,omitempty"`
 }
 
 // Message quote. Only the Text property is warrantied to be present, all other fields are optional.
@@ -145,6 +151,19 @@ type MsgQuote struct {
 	ViewType           MsgType
 }
 
+// Information needed to show a notification for a message, see Rpc.GetMessageNotificationInfo().
+type MsgNotificationInfo struct {
+	Id               MsgId
+	ChatId           ChatId
+	AccountId        AccountId
+	Image            string // Path of the attached image, if any
+	ImageMimeType    string
+	ChatName         string
+	ChatProfileImage string
+	SummaryPrefix    string // Sender name in groups, empty otherwise
+	SummaryText      string
+}
+
 // Message search result.
 type MsgSearchResult struct {
 	Id                 MsgId
@@ -180,6 +199,7 @@ type MsgSnapshot struct {
 	IsForwarded           bool
 	IsBot                 bool
 	SystemMessageType     SysmsgType
+	InfoContactId         ContactId // Contact an info message is about, e.g. the added member; only set by newer core versions
 	Duration              int
 	DimensionsHeight      int
 	DimensionsWidth       int
@@ -206,7 +226,46 @@ type WebxdcMsgInfo struct {
 	InternetAccess bool
 }
 
+// HTTP response fetched with Rpc.GetHttpResponse().
+type HttpResponse struct {
+	Blob     []byte // Response body, decoded from base64
+	Mimetype string
+	Encoding string
+}
+
+// UnmarshalJSON decodes the base64 body with or without padding, the core sends it unpadded.
+func (self *HttpResponse) UnmarshalJSON(b []byte) error {
+	var resp struct {
+		Blob     string
+		Mimetype string
+		Encoding string
+	}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return err
+	}
+	blob, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(resp.Blob, "="))
+	if err != nil {
+		return err
+	}
+	*self = HttpResponse{Blob: blob, Mimetype: resp.Mimetype, Encoding: resp.Encoding}
+	return nil
+}
+
 type Reactions struct {
 	ReactionsByContact map[ContactId][]string
 	Reactions          map[string]int // Unique reactions and their count
 }
+
+// Location shared by a contact, see Rpc.GetLocations().
+type Location struct {
+	LocationId    uint64
+	IsIndependent bool
+	Latitude      float64
+	Longitude     float64
+	Accuracy      float64
+	Timestamp     Timestamp
+	ContactId     ContactId
+	MsgId         MsgId
+	ChatId        ChatId
+	Marker        string
+}
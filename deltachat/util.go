@@ -28,8 +28,13 @@ func ParseMemberRemoved(rpc Rpc, accountId AccountId, msg *MsgSnapshot) (actor C
 }
 
 func parseMemberAddRemove(rpc Rpc, accountId AccountId, msg *MsgSnapshot, action string) (actor ContactId, target ContactId, err error) {
-	text := strings.ToLower(msg.Text)
 	actor = msg.FromId
+	if msg.InfoContactId != 0 {
+		return actor, msg.InfoContactId, nil
+	}
+
+	// Older core versions don't provide MsgSnapshot.InfoContactId, parse the (English) message text.
+	text := strings.ToLower(msg.Text)
 
 	regex := regexp.MustCompile(`^member (.+) ` + action + ` by .+\.$`)
 	match := regex.FindStringSubmatch(text)
@@ -7,6 +7,7 @@ toolchain go1.21.0
 require (
 	github.com/creachadair/jrpc2 v1.1.2
 	github.com/stretchr/testify v1.8.2
+	golang.org/x/term v0.18.0
 )
 
 require (
@@ -14,5 +15,6 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sync v0.6.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
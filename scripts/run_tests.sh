@@ -53,5 +53,13 @@ fi
 cd ../..
 echo "Done testing examples"
 
+echo "Testing deltachat/setup module"
+cd deltachat/setup
+if ! go test -v
+then
+    exit 1
+fi
+cd ../..
+
 courtney -v -t="./..." ${TEST_EXTRA_TAGS:--t="-parallel=1"}
 go tool cover -func=coverage.out -o=coverage-percent.out